@@ -8,7 +8,7 @@ terraform {
 
 provider "pinto" {
   base_url       = "https://test.camao.domains.fascicularis.de"
-  token_url      = "https://auth.test.camao.domains.fascicularis.de/connect/token"
+  issuer_url     = "https://auth.test.camao.domains.fascicularis.de"
   client_id      = ""
   client_secret  = ""
   client_scope   = ""
@@ -38,3 +38,11 @@ resource "pinto_dns_record" "test_record_2" {
   data              = "127.0.0.1"
   ttl               = 1800
 }
+
+resource "pinto_dns_record_set" "test_round_robin" {
+  zone              = pinto_dns_zone.created_zone.name
+  name              = "roundrobin"
+  type              = "A"
+  ttl               = 1800
+  records           = ["127.0.0.1", "127.0.0.2"]
+}
@@ -6,10 +6,13 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 )
 
+// version is set by goreleaser
+var version = "dev"
+
 func main() {
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: func() *schema.Provider {
-			return pinto.NewDefaultProvider()
+			return pinto.NewDefaultProvider(version)
 		},
 	})
 }
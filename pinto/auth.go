@@ -0,0 +1,178 @@
+package pinto
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authModeApiKey            = "api_key"
+	authModeClientCredentials = "client_credentials"
+	authModeAccessToken       = "access_token"
+	authModeTokenFile         = "token_file"
+	authModeCredentialProcess = "credential_process"
+
+	// tokens read from a token file without a readable expiry are re-read after this interval
+	tokenFileReloadInterval = time.Minute
+)
+
+// authentication is the outcome of configureAuthentication
+type authentication struct {
+	mode string
+	// tokenSource is nil if no token is needed
+	tokenSource oauth2.TokenSource
+	apiKey      string
+}
+
+// configureAuthentication picks exactly one authentication mode and returns the token source for the Pinto API or the
+// api key
+func configureAuthentication(settings providerSettings, tokenCtx context.Context) (authentication, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var processTokenSource oauth2.TokenSource
+
+	command, ok := settings.GetOk(schemaCredentialProcess)
+	if ok {
+		process := &credentialProcess{command: command}
+		output, err := process.run()
+		if err != nil {
+			return authentication{}, append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Unable to fetch credentials",
+				Detail:   err.Error(),
+			})
+		}
+		// secrets of the credential process rank below explicitly configured values, but above the profile
+		if output.ClientSecret != "" {
+			settings.profile[schemaClientSecret] = output.ClientSecret
+		}
+		if output.ApiKey != "" {
+			settings.profile[schemaApiKey] = output.ApiKey
+		}
+		if output.AccessToken != "" {
+			processTokenSource = oauth2.ReuseTokenSource(output.token(), process)
+		}
+	}
+
+	var modes []string
+	if _, ok := settings.GetOk(schemaApiKey); ok {
+		modes = append(modes, authModeApiKey)
+	}
+	if _, ok := settings.GetOk(schemaClientId); ok {
+		modes = append(modes, authModeClientCredentials)
+	}
+	if _, ok := settings.GetOk(schemaAccessToken); ok {
+		modes = append(modes, authModeAccessToken)
+	}
+	if _, ok := settings.GetOk(schemaTokenFile); ok {
+		modes = append(modes, authModeTokenFile)
+	}
+	if processTokenSource != nil {
+		modes = append(modes, authModeCredentialProcess)
+	}
+	if len(modes) > 1 {
+		return authentication{}, append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Conflicting authentication modes",
+			Detail: fmt.Sprintf("Exactly one authentication mode can be used, but %s are configured. "+
+				"Remove all but one of them from the provider configuration, environment, profile or credential process.", strings.Join(modes, ", ")),
+		})
+	}
+	if len(modes) == 0 {
+		log.Printf("[WARN] Pinto: No authentication configured")
+		return authentication{}, diags
+	}
+	mode := modes[0]
+	log.Printf("[DEBUG] Pinto: Using authentication mode %s", mode)
+
+	switch mode {
+	case authModeApiKey:
+		return authentication{mode: mode, apiKey: settings.Get(schemaApiKey)}, diags
+	case authModeAccessToken:
+		return authentication{mode: mode, tokenSource: oauth2.StaticTokenSource(&oauth2.Token{
+			AccessToken: settings.Get(schemaAccessToken),
+			TokenType:   "Bearer",
+		})}, diags
+	case authModeTokenFile:
+		return authentication{mode: mode, tokenSource: oauth2.ReuseTokenSource(nil, &tokenFileSource{path: settings.Get(schemaTokenFile)})}, diags
+	case authModeCredentialProcess:
+		return authentication{mode: mode, tokenSource: processTokenSource}, diags
+	}
+
+	oAuthConf, err := configureOAuthClient(tokenCtx, settings)
+	if err != nil {
+		return authentication{}, append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Unable to create Pinto client",
+			Detail:   err.Error(),
+		})
+	}
+	// TODO: Do we need to move this into a utils class and use a different context each time?
+	tokenSource := oAuthConf.TokenSource(tokenCtx)
+	key, ok := settings.GetOk(schemaClientAssertionKey)
+	if ok {
+		signer, err := configureJwtSigner(key, settings.Get(schemaClientAssertionKeyId), settings.Get(schemaClientAssertionAlgorithm))
+		if err != nil {
+			return authentication{}, append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Invalid " + schemaClientAssertionKey,
+				Detail:   err.Error(),
+			})
+		}
+		tokenSource = newJwtAssertionTokenSource(tokenCtx, oAuthConf, signer)
+	}
+	dir, ok := settings.GetOk(schemaTokenCacheDir)
+	if ok {
+		tokenSource, err = newCachedTokenSource(dir, oAuthConf.TokenURL, oAuthConf.ClientID, oAuthConf.Scopes, tokenSource)
+		if err != nil {
+			return authentication{}, append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Unable to setup token cache",
+				Detail:   err.Error(),
+			})
+		}
+	}
+	return authentication{mode: mode, tokenSource: tokenSource}, diags
+}
+
+// tokenFileSource reads a bearer token from a file which is rotated externally, e.g. a projected service account
+// token. The file is re-read whenever the token expires
+type tokenFileSource struct {
+	path string
+}
+
+func (s *tokenFileSource) Token() (*oauth2.Token, error) {
+	path, err := expandPath(s.path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", schemaTokenFile, err)
+	}
+	accessToken := strings.TrimSpace(string(b))
+	if accessToken == "" {
+		return nil, fmt.Errorf("%s %s is empty", schemaTokenFile, path)
+	}
+
+	token := &oauth2.Token{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(tokenFileReloadInterval),
+	}
+	claims, err := decodeJwtClaims(accessToken)
+	if err == nil {
+		expiry, ok := jwtExpiry(claims)
+		if ok {
+			token.Expiry = expiry
+		}
+	}
+	log.Printf("[DEBUG] Pinto: Read token from %s, expiring at %s", path, token.Expiry)
+	return token, nil
+}
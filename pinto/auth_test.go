@@ -0,0 +1,62 @@
+package pinto
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureAuthenticationRejectsConflictingModes(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider(nil).Schema, map[string]interface{}{
+		schemaApiKey:      "key",
+		schemaAccessToken: "token",
+	})
+	_, diags := configureAuthentication(providerSettings{d: d, profile: profile{}}, context.Background())
+	require.True(t, diags.HasError())
+	require.Equal(t, "Conflicting authentication modes", diags[0].Summary)
+	require.Contains(t, diags[0].Detail, "api_key, access_token are configured")
+}
+
+func TestConfigureAuthenticationAccessToken(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider(nil).Schema, map[string]interface{}{
+		schemaAccessToken: "token",
+	})
+	auth, diags := configureAuthentication(providerSettings{d: d, profile: profile{}}, context.Background())
+	require.False(t, diags.HasError())
+	require.Equal(t, authModeAccessToken, auth.mode)
+	require.Equal(t, "", auth.apiKey)
+	token, err := auth.tokenSource.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token", token.AccessToken)
+}
+
+func TestTokenFileSourceUsesJwtExpiry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinto-token-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"ci","exp":%d}`, expiry.Unix())))
+	jwt := "eyJhbGciOiJSUzI1NiJ9." + payload + ".c2lnbmF0dXJl"
+	path := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(path, []byte(jwt+"\n"), 0600))
+
+	token, err := (&tokenFileSource{path: path}).Token()
+	require.NoError(t, err)
+	require.Equal(t, jwt, token.AccessToken)
+	require.True(t, expiry.Equal(token.Expiry))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("opaque"), 0600))
+	token, err = (&tokenFileSource{path: path}).Token()
+	require.NoError(t, err)
+	require.Equal(t, "opaque", token.AccessToken)
+	require.True(t, token.Expiry.Before(time.Now().Add(tokenFileReloadInterval+time.Second)))
+}
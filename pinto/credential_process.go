@@ -0,0 +1,129 @@
+package pinto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const credentialProcessTimeout = time.Minute
+
+// credentialProcessOutput is the JSON document a credential process has to print to stdout
+type credentialProcessOutput struct {
+	Version      int        `json:"Version"`
+	ClientSecret string     `json:"ClientSecret"`
+	ApiKey       string     `json:"ApiKey"`
+	AccessToken  string     `json:"AccessToken"`
+	TokenType    string     `json:"TokenType"`
+	Expiration   *time.Time `json:"Expiration"`
+}
+
+func (o credentialProcessOutput) token() *oauth2.Token {
+	token := &oauth2.Token{
+		AccessToken: o.AccessToken,
+		TokenType:   o.TokenType,
+	}
+	if token.TokenType == "" {
+		token.TokenType = "Bearer"
+	}
+	if o.Expiration != nil {
+		token.Expiry = *o.Expiration
+	}
+	return token
+}
+
+// credentialProcess runs an external command to fetch secrets at runtime, so they never have to be written to disk.
+// As a token source it re-runs the command whenever the returned access token expired
+type credentialProcess struct {
+	command string
+}
+
+func (p *credentialProcess) run() (credentialProcessOutput, error) {
+	var output credentialProcessOutput
+	args, err := splitCommand(p.command)
+	if err != nil {
+		return output, fmt.Errorf("invalid %s: %v", schemaCredentialProcess, err)
+	}
+	if len(args) == 0 {
+		return output, fmt.Errorf("invalid %s: command is empty", schemaCredentialProcess)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), credentialProcessTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.Printf("[DEBUG] Pinto: Running credential process %s", args[0])
+	err = cmd.Run()
+	if err != nil {
+		return output, fmt.Errorf("credential process %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	err = json.Unmarshal(stdout.Bytes(), &output)
+	if err != nil {
+		// the output is not part of the error, because it may contain secrets
+		return output, fmt.Errorf("credential process %s returned invalid JSON: %v", args[0], err)
+	}
+	if output.Version != 1 {
+		return output, fmt.Errorf("credential process %s returned unsupported version %d, expected 1", args[0], output.Version)
+	}
+	if output.ClientSecret == "" && output.ApiKey == "" && output.AccessToken == "" {
+		return output, fmt.Errorf("credential process %s returned neither ClientSecret, ApiKey nor AccessToken", args[0])
+	}
+	return output, nil
+}
+
+func (p *credentialProcess) Token() (*oauth2.Token, error) {
+	output, err := p.run()
+	if err != nil {
+		return nil, err
+	}
+	if output.AccessToken == "" {
+		return nil, fmt.Errorf("credential process did not return an AccessToken anymore")
+	}
+	return output.token(), nil
+}
+
+// splitCommand splits a command line into its arguments. Arguments may be quoted with single or double quotes
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, c := range command {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(c)
+		case c == '"' || c == '\'':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,38 @@
+package pinto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommand(t *testing.T) {
+	args, err := splitCommand(`vault-cli read  "secret/pinto client" --format='json'`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"vault-cli", "read", "secret/pinto client", "--format=json"}, args)
+
+	_, err = splitCommand(`vault-cli read "secret`)
+	require.Error(t, err)
+}
+
+func TestCredentialProcessToken(t *testing.T) {
+	process := &credentialProcess{
+		command: `echo '{"Version": 1, "AccessToken": "token", "Expiration": "2030-01-01T00:00:00Z"}'`,
+	}
+	token, err := process.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token", token.AccessToken)
+	require.Equal(t, "Bearer", token.TokenType)
+	require.True(t, token.Expiry.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestCredentialProcessRejectsInvalidOutput(t *testing.T) {
+	process := &credentialProcess{command: `echo '{"Version": 2, "ApiKey": "key"}'`}
+	_, err := process.run()
+	require.EqualError(t, err, "credential process echo returned unsupported version 2, expected 1")
+
+	process = &credentialProcess{command: `echo '{"Version": 1}'`}
+	_, err = process.run()
+	require.EqualError(t, err, "credential process echo returned neither ClientSecret, ApiKey nor AccessToken")
+}
@@ -0,0 +1,163 @@
+package pinto
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceCallerIdentity() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCallerIdentityRead,
+		Schema: map[string]*schema.Schema{
+			schemaProvider: {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			schemaEnvironment: {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"auth_mode": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"issuer": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"subject": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"client_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"scopes": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"expires_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// callerIdentity is decoded from the access token. All fields stay empty for api keys and opaque tokens
+type callerIdentity struct {
+	issuer    string
+	subject   string
+	clientId  string
+	scopes    []string
+	expiresAt time.Time
+}
+
+func decodeCallerIdentity(accessToken string, expiry time.Time) callerIdentity {
+	identity := callerIdentity{expiresAt: expiry}
+	claims, err := decodeJwtClaims(accessToken)
+	if err != nil {
+		log.Printf("[DEBUG] Pinto: Access token is opaque, only its expiry is known: %v", err)
+		return identity
+	}
+	identity.issuer, _ = claims["iss"].(string)
+	identity.subject, _ = claims["sub"].(string)
+	// the claim of the client differs between authorization servers
+	for _, claim := range []string{"client_id", "azp", "cid"} {
+		clientId, ok := claims[claim].(string)
+		if ok {
+			identity.clientId = clientId
+			break
+		}
+	}
+	// scopes are either a space-separated "scope" (RFC 8693) or a "scp" array
+	switch scopes := claims["scope"].(type) {
+	case string:
+		identity.scopes = strings.Fields(scopes)
+	case []interface{}:
+		for _, scope := range scopes {
+			identity.scopes = append(identity.scopes, fmt.Sprint(scope))
+		}
+	}
+	if identity.scopes == nil {
+		scp, _ := claims["scp"].([]interface{})
+		for _, scope := range scp {
+			identity.scopes = append(identity.scopes, fmt.Sprint(scope))
+		}
+	}
+	exp, ok := jwtExpiry(claims)
+	if ok {
+		identity.expiresAt = exp
+	}
+	return identity
+}
+
+func dataSourceCallerIdentityRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pinto := m.(*PintoProvider)
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	var identity callerIdentity
+	if pinto.tokenSource != nil {
+		token, err := pinto.tokenSource.Token()
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Unable to retrieve access token",
+				Detail:   err.Error(),
+			})
+			return diags
+		}
+		identity = decodeCallerIdentity(token.AccessToken, token.Expiry)
+	}
+	log.Printf("[INFO] Pinto: Authenticated with %s as %s in environment %s of provider %s", pinto.authMode, identity.subject,
+		pinto.environment, pinto.provider)
+
+	values := map[string]interface{}{
+		schemaProvider:      pinto.provider,
+		schemaEnvironment:   pinto.environment,
+		schemaCredentialsId: pinto.credentialsId,
+		"auth_mode":         pinto.authMode,
+		"issuer":            identity.issuer,
+		"subject":           identity.subject,
+		"client_id":         identity.clientId,
+		"scopes":            identity.scopes,
+		"expires_at":        "",
+	}
+	if !identity.expiresAt.IsZero() {
+		values["expires_at"] = identity.expiresAt.UTC().Format(time.RFC3339)
+	}
+	for key, value := range values {
+		err := d.Set(key, value)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	id := identity.subject
+	if id == "" {
+		id = identity.clientId
+	}
+	if id == "" {
+		id = pinto.authMode
+	}
+	if id == "" {
+		// without any authentication
+		id = "anonymous"
+	}
+	d.SetId(id)
+
+	return diags
+}
@@ -0,0 +1,48 @@
+package pinto
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestDecodeCallerIdentity(t *testing.T) {
+	// header and signature are not verified
+	token := "eyJhbGciOiJSUzI1NiJ9." +
+		"eyJpc3MiOiJodHRwczovL2F1dGgubW9jay5jbyIsInN1YiI6InBpcGVsaW5lIiwiY2xpZW50X2lkIjoidGVycmFmb3JtIiwic2NvcGUiOiJkbnMucmVhZCBkbnMud3JpdGUiLCJleHAiOjE3MDAwMDAwMDB9." +
+		"c2lnbmF0dXJl"
+	identity := decodeCallerIdentity(token, time.Time{})
+	require.Equal(t, "https://auth.mock.co", identity.issuer)
+	require.Equal(t, "pipeline", identity.subject)
+	require.Equal(t, "terraform", identity.clientId)
+	require.Equal(t, []string{"dns.read", "dns.write"}, identity.scopes)
+	require.Equal(t, time.Unix(1700000000, 0), identity.expiresAt)
+
+	expiry := time.Now().Add(time.Hour)
+	identity = decodeCallerIdentity("opaque-token", expiry)
+	require.Equal(t, "", identity.subject)
+	require.Equal(t, expiry, identity.expiresAt)
+}
+
+func TestDataSourceCallerIdentityRead(t *testing.T) {
+	p := &PintoProvider{
+		provider:      "digitalocean",
+		environment:   "prod1",
+		credentialsId: "4d4fe4ac",
+		authMode:      authModeAccessToken,
+		tokenSource:   oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "opaque-token"}),
+	}
+	d := schema.TestResourceDataRaw(t, dataSourceCallerIdentity().Schema, map[string]interface{}{})
+	diags := dataSourceCallerIdentityRead(context.Background(), d, p)
+	require.False(t, diags.HasError())
+	require.Equal(t, authModeAccessToken, d.Id())
+	require.Equal(t, "digitalocean", d.Get(schemaProvider))
+	require.Equal(t, "prod1", d.Get(schemaEnvironment))
+	require.Equal(t, "4d4fe4ac", d.Get(schemaCredentialsId))
+	require.Equal(t, authModeAccessToken, d.Get("auth_mode"))
+	require.Equal(t, "", d.Get("expires_at"))
+}
@@ -21,6 +21,10 @@ func dataSourceDnsRecord() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 			"id": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -53,7 +57,7 @@ func dataSourceDnsRecord() *schema.Resource {
 	}
 }
 
-func recordToRecord(r gopinto.Record, zone string, environment string, provider string) Record {
+func recordToRecord(r gopinto.Record, zone string, environment string, provider string, credentialsId string) Record {
 	var record Record
 	record.zone = zone
 	record.Name = r.Name
@@ -63,6 +67,7 @@ func recordToRecord(r gopinto.Record, zone string, environment string, provider
 	record.Ttl = r.Ttl
 	record.provider = provider
 	record.environment = environment
+	record.credentialsId = credentialsId
 	return record
 }
 
@@ -88,24 +93,32 @@ func dataSourceDnsRecordRead(ctx context.Context, d *schema.ResourceData, m inte
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	xApiOptions, err := buildXApiOptions(provider, environment, getCredentialsId(pinto, d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	request := pinto.client.RecordsApi.DnsApiRecordsGet(pctx).
 		Zone(zone).
 		Name(name).
-		XApiOptions(pinto.xApiOptions).
+		XApiOptions(xApiOptions).
 		RecordType(gopinto.RecordType(_type))
 
 	r, resp, gErr := request.Execute()
 
-	if resp.StatusCode >= 400 {
+	if resp == nil || resp.StatusCode >= 400 {
 		return diag.Errorf(handleClientError("[DS] RECORD READ", gErr.Error(), resp))
 	}
+	if len(r) == 0 {
+		return diag.Errorf("No record found with (name=%s, zone=%s, type=%s, provider=%s, environment=%s)",
+			name, zone, _type, provider, environment)
+	}
 	if len(r) > 1 {
 		return diag.Errorf("Cannot uniquely identify a resource with (name=%s, zone=%s, type=%s, provider=%s, environment=%s). "+
-			"Wanted 1, got %d", name, zone, _type, pinto.provider, pinto.environment, len(r))
+			"Wanted 1, got %d", name, zone, _type, provider, environment, len(r))
 	}
 
-	record := recordToRecord(r[0], zone, environment, provider)
+	record := recordToRecord(r[0], zone, environment, provider, getResourceCredentialsId(d))
 	record.id = computeRecordId(record)
 
 	d.SetId(record.id)
@@ -121,9 +134,11 @@ func dataSourceDnsRecordRead(ctx context.Context, d *schema.ResourceData, m inte
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("ttl", *record.Ttl)
-	if err != nil {
-		return diag.FromErr(err)
+	if record.HasTtl() {
+		err = d.Set("ttl", *record.Ttl)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
 	err = d.Set("class", record.Class)
 	if err != nil {
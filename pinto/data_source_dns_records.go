@@ -22,6 +22,10 @@ func dataSourceDnsRecords() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 			"id": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -91,10 +95,15 @@ func dataSourceDnsRecordsRead(ctx context.Context, d *schema.ResourceData, m int
 		return diag.FromErr(err)
 	}
 
+	xApiOptions, err := buildXApiOptions(provider, environment, getCredentialsId(pinto, d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	zone := d.Get("zone").(string)
 	log.Printf("[INFO] Pinto: Read records from zone %s at %s for %s \n", zone, provider, environment)
 
-	request := pinto.client.RecordsApi.DnsApiRecordsGet(pctx).Zone(zone).XApiOptions(pinto.xApiOptions)
+	request := pinto.client.RecordsApi.DnsApiRecordsGet(pctx).Zone(zone).XApiOptions(xApiOptions)
 	val, ok := d.GetOk("record_type")
 	if ok {
 		request.RecordType(gopinto.RecordType(val.(string)))
@@ -106,15 +115,23 @@ func dataSourceDnsRecordsRead(ctx context.Context, d *schema.ResourceData, m int
 
 	rrecords, resp, err := request.Execute()
 
-	if resp.StatusCode >= 400 {
+	if resp == nil || resp.StatusCode >= 400 {
 		return diag.Errorf(handleClientError("[DS] RECORD READ", err.Error(), resp))
 	}
 
 	records := make([]interface{}, len(rrecords), len(rrecords))
+	// the ids of records whose set has further values contain the data, as the ids of pinto_dns_record
+	setSizes := make(map[string]int)
+	for _, r := range rrecords {
+		setSizes[string(r.Type)+"/"+r.Name]++
+	}
 
 	for i, r := range rrecords {
-		idRecord := recordToRecord(r, zone, environment, provider)
+		idRecord := recordToRecord(r, zone, environment, provider, getResourceCredentialsId(d))
 		idRecord.id = computeRecordId(idRecord)
+		if setSizes[string(r.Type)+"/"+r.Name] > 1 {
+			idRecord.id = computeSharedRecordId(idRecord)
+		}
 		record := make(map[string]interface{})
 		record["name"] = r.Name
 		record["type"] = r.Type
@@ -126,7 +143,7 @@ func dataSourceDnsRecordsRead(ctx context.Context, d *schema.ResourceData, m int
 	}
 
 	zoneId := strings.TrimSuffix(zone, ".") + "."
-	d.SetId(zoneId + environment + "." + provider + ".")
+	d.SetId(zoneId + environment + "." + providerIdSegment(provider, getResourceCredentialsId(d)) + ".")
 	e := d.Set("records", records)
 	if e != nil {
 		return diag.FromErr(err)
@@ -22,6 +22,10 @@ func dataSourceDnsZone() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 			"id": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -48,11 +52,15 @@ func dataSourceDnsZoneRead(ctx context.Context, d *schema.ResourceData, m interf
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	log.Printf("[INFO] Pinto: Read Zone %s at %s for %s with %v \n", zone.name, zone.provider, zone.environment, pinto.xApiOptions)
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	log.Printf("[INFO] Pinto: Read Zone %s at %s for %s with %v \n", zone.name, zone.provider, zone.environment, redactJson([]byte(xApiOptions)))
 
 	request := pinto.client.ZonesApi.
 		DnsApiZonesZoneGet(pctx, zone.name).
-		XApiOptions(pinto.xApiOptions)
+		XApiOptions(xApiOptions)
 
 	_, resp, err := request.Execute()
 
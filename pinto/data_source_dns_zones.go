@@ -21,6 +21,10 @@ func dataSourceDnsZones() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 			"id": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -61,20 +65,26 @@ func dataSourceDnsZonesRead(ctx context.Context, d *schema.ResourceData, m inter
 		return diag.FromErr(err)
 	}
 
-	log.Printf("[INFO] Pinto: Read zones at %s for %s \n", pinto.provider, pinto.environment)
+	xApiOptions, err := buildXApiOptions(provider, environment, getCredentialsId(pinto, d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[INFO] Pinto: Read zones at %s for %s \n", provider, environment)
 
-	request := pinto.client.ZonesApi.DnsApiZonesGet(pctx).XApiOptions(pinto.xApiOptions)
+	request := pinto.client.ZonesApi.DnsApiZonesGet(pctx).XApiOptions(xApiOptions)
 	rz, resp, err := request.Execute()
-	if resp.StatusCode >= 400 {
+	if resp == nil || resp.StatusCode >= 400 {
 		return diag.Errorf(handleClientError("[DS] ZONES READ", err.Error(), resp))
 	}
 
 	zones := make([]interface{}, len(rz), len(rz))
 	for i, z := range rz {
 		temp := Zone{
-			name:        z.Name,
-			environment: environment,
-			provider:    provider,
+			name:          z.Name,
+			environment:   environment,
+			provider:      provider,
+			credentialsId: getResourceCredentialsId(d),
 		}
 		zone := make(map[string]interface{})
 		zone["id"] = computeZoneId(temp)
@@ -82,7 +92,7 @@ func dataSourceDnsZonesRead(ctx context.Context, d *schema.ResourceData, m inter
 		zones[i] = zone
 	}
 
-	d.SetId(environment + "." + provider + ".")
+	d.SetId(environment + "." + providerIdSegment(provider, getResourceCredentialsId(d)) + ".")
 	e := d.Set("zones", zones)
 	if e != nil {
 		return diag.FromErr(err)
@@ -0,0 +1,194 @@
+package pinto
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
+)
+
+const (
+	// enables the wire logging if TF_LOG is DEBUG or TRACE too
+	envKeyHttpDebug = "PINTO_HTTP_DEBUG"
+
+	redacted = "REDACTED"
+)
+
+// sensitiveHeaders are never logged
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveFields are redacted from JSON and form bodies and from the X-Api-Options header
+var sensitiveFields = []string{
+	"client_secret",
+	"client_assertion",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"credentials_id",
+	"password",
+}
+
+func httpDebugEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(envKeyHttpDebug))
+	return enabled && logging.IsDebugOrHigher()
+}
+
+// debugTransport logs every request and response including their bodies. Credentials are redacted, so the logs can be
+// shared safely
+type debugTransport struct {
+	base http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// the request must not be modified, so the copy gets the buffered body
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+	log.Printf("[DEBUG] Pinto: HTTP request %s %s\n%s\n%s", req.Method, redactUrl(req.URL), redactHeaders(req.Header),
+		redactBody(req.Header.Get("Content-Type"), reqBody))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start)
+	if err != nil {
+		log.Printf("[DEBUG] Pinto: HTTP request %s %s failed after %s: %v", req.Method, redactUrl(req.URL), latency, err)
+		return resp, err
+	}
+
+	respBody, readErr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	var body io.Reader = bytes.NewReader(respBody)
+	if readErr != nil {
+		// the error surfaces when the caller reads the body
+		body = io.MultiReader(body, errReader{readErr})
+	}
+	resp.Body = ioutil.NopCloser(body)
+	log.Printf("[DEBUG] Pinto: HTTP response %s %s: %s in %s\n%s\n%s", req.Method, redactUrl(req.URL), resp.Status, latency,
+		redactHeaders(resp.Header), redactBody(resp.Header.Get("Content-Type"), respBody))
+	return resp, nil
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func redactUrl(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	c := *u
+	c.User = url.User(redacted)
+	return c.String()
+}
+
+func redactHeaders(header http.Header) string {
+	var lines []string
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		switch {
+		case isSensitiveHeader(name):
+			value = redacted
+		case http.CanonicalHeaderKey(name) == "X-Api-Options":
+			value = redactJson([]byte(value))
+		}
+		lines = append(lines, name+": "+value)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[unparsable form body with " + strconv.Itoa(len(body)) + " bytes]"
+		}
+		for key := range values {
+			if isSensitiveField(key) {
+				values.Set(key, redacted)
+			}
+		}
+		return values.Encode()
+	case strings.HasSuffix(mediaType, "json"):
+		return redactJson(body)
+	}
+	return string(body)
+}
+
+// redactJson replaces the values of all sensitive fields, no matter how deeply they are nested
+func redactJson(body []byte) string {
+	var v interface{}
+	err := json.Unmarshal(body, &v)
+	if err != nil {
+		return "[unparsable JSON with " + strconv.Itoa(len(body)) + " bytes]"
+	}
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return "[unparsable JSON with " + strconv.Itoa(len(body)) + " bytes]"
+	}
+	return string(b)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if isSensitiveField(key) {
+				value[key] = redacted
+			} else {
+				value[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+	return v
+}
+
+func isSensitiveHeader(name string) bool {
+	for _, h := range sensitiveHeaders {
+		if http.CanonicalHeaderKey(name) == h {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveField(name string) bool {
+	for _, f := range sensitiveFields {
+		if strings.EqualFold(name, f) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package pinto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+const openIdConfigurationPath = "/.well-known/openid-configuration"
+
+// discoveredTokenUrls caches the token endpoints per issuer, so the discovery document is fetched only once
+var discoveredTokenUrls = struct {
+	sync.Mutex
+	urls map[string]string
+}{urls: map[string]string{}}
+
+type openIdConfiguration struct {
+	Issuer        string `json:"issuer"`
+	TokenEndpoint string `json:"token_endpoint"`
+}
+
+// discoverTokenUrl returns the token endpoint announced in the OpenID Connect discovery document of issuerUrl.
+// The request uses the http client of ctx, so TLS and proxy settings apply
+func discoverTokenUrl(ctx context.Context, issuerUrl string) (string, error) {
+	issuer := strings.TrimSuffix(issuerUrl, "/")
+
+	discoveredTokenUrls.Lock()
+	defer discoveredTokenUrls.Unlock()
+	tokenUrl, ok := discoveredTokenUrls.urls[issuer]
+	if ok {
+		return tokenUrl, nil
+	}
+
+	client := http.DefaultClient
+	c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	if ok {
+		client = c
+	}
+	discoveryUrl := issuer + openIdConfigurationPath
+	log.Printf("[DEBUG] Pinto: Fetching OpenID configuration from %s", discoveryUrl)
+	req, err := http.NewRequest(http.MethodGet, discoveryUrl, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %s: %v", schemaIssuerUrl, issuerUrl, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch OpenID configuration from %s: %v", discoveryUrl, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch OpenID configuration from %s: %v", discoveryUrl, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to fetch OpenID configuration from %s: %s", discoveryUrl, resp.Status)
+	}
+
+	var conf openIdConfiguration
+	err = json.Unmarshal(body, &conf)
+	if err != nil {
+		return "", fmt.Errorf("malformed OpenID configuration at %s: %v", discoveryUrl, err)
+	}
+	if strings.TrimSuffix(conf.Issuer, "/") != issuer {
+		return "", fmt.Errorf("malformed OpenID configuration at %s: issuer %q does not match %s %q", discoveryUrl, conf.Issuer, schemaIssuerUrl, issuerUrl)
+	}
+	u, err := url.Parse(conf.TokenEndpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("malformed OpenID configuration at %s: invalid token_endpoint %q", discoveryUrl, conf.TokenEndpoint)
+	}
+
+	log.Printf("[DEBUG] Pinto: Discovered token endpoint %s", conf.TokenEndpoint)
+	discoveredTokenUrls.urls[issuer] = conf.TokenEndpoint
+	return conf.TokenEndpoint, nil
+}
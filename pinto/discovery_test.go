@@ -0,0 +1,47 @@
+package pinto
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverTokenUrl(t *testing.T) {
+	requests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, openIdConfigurationPath, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"issuer":"` + server.URL + `","token_endpoint":"` + server.URL + `/connect/token"}`))
+	}))
+	defer server.Close()
+
+	tokenUrl, err := discoverTokenUrl(context.Background(), server.URL+"/")
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/connect/token", tokenUrl)
+
+	_, err = discoverTokenUrl(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Equal(t, 1, requests, "the discovery document should be cached")
+}
+
+func TestDiscoverTokenUrlMalformedDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"issuer":"https://other.mock.co"}`))
+	}))
+	defer server.Close()
+
+	_, err := discoverTokenUrl(context.Background(), server.URL)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "malformed OpenID configuration")
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	_, err = discoverTokenUrl(context.Background(), missing.URL)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "404 Not Found")
+}
@@ -0,0 +1,137 @@
+package pinto
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// checkWritable refuses mutations if the provider is read only. It is the runtime guard behind
+// customizeDiffReadOnly, which cannot catch deletions because CustomizeDiff does not run on destroy
+func checkWritable(p *PintoProvider, op string, subject string) error {
+	if p.readOnly {
+		return fmt.Errorf("refusing to %s %s, because the provider is configured with %s = true", op, subject, schemaReadOnly)
+	}
+	return nil
+}
+
+// customizeDiffReadOnly fails the plan of any create, update or replacement if the provider is read only
+func customizeDiffReadOnly(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	p, ok := m.(*PintoProvider)
+	if !ok || !p.readOnly {
+		return nil
+	}
+	if d.Id() == "" {
+		return fmt.Errorf("unable to create this resource, because the provider is configured with %s = true", schemaReadOnly)
+	}
+	changed := d.GetChangedKeysPrefix("")
+	if len(changed) > 0 {
+		return fmt.Errorf("unable to change %v of %s, because the provider is configured with %s = true", changed, d.Id(), schemaReadOnly)
+	}
+	return nil
+}
+
+// checkZoneAllowed refuses mutations of zones which match a pattern of denied_zones or, if allowed_zones is set, do not
+// match any of its patterns. Denied zones take precedence
+func checkZoneAllowed(p *PintoProvider, op string, subject string, zone string) error {
+	z := normalizeZone(zone)
+	for _, pattern := range p.deniedZones {
+		if matchZone(pattern, z) {
+			return fmt.Errorf("refusing to %s %s, because zone %s matches %q of %s", op, subject, z, pattern, schemaDeniedZones)
+		}
+	}
+	if len(p.allowedZones) == 0 {
+		return nil
+	}
+	for _, pattern := range p.allowedZones {
+		if matchZone(pattern, z) {
+			return nil
+		}
+	}
+	return fmt.Errorf("refusing to %s %s, because zone %s does not match any pattern of %s %q", op, subject, z,
+		schemaAllowedZones, p.allowedZones)
+}
+
+// customizeDiffZoneRules fails the plan of any create, update or replacement touching a zone refused by
+// checkZoneAllowed. zoneKey is the attribute holding the zone, both its old and new value have to be allowed
+func customizeDiffZoneRules(zoneKey string) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+		p, ok := m.(*PintoProvider)
+		if !ok || !isMutation(d) {
+			return nil
+		}
+		op, subject := "change", d.Id()
+		if d.Id() == "" {
+			op, subject = "create", "this resource"
+		}
+		o, n := d.GetChange(zoneKey)
+		if d.Id() != "" {
+			err := checkZoneAllowed(p, op, subject, o.(string))
+			if err != nil {
+				return err
+			}
+		}
+		// the new zone is unknown during plan if it depends on other resources, then only the runtime check applies
+		if !d.NewValueKnown(zoneKey) {
+			return nil
+		}
+		return checkZoneAllowed(p, op, subject, n.(string))
+	}
+}
+
+// validateZonePatterns checks the syntax of allowed_zones and denied_zones
+func validateZonePatterns(key string, patterns []string) error {
+	for _, pattern := range patterns {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q in %s: %v", pattern, key, err)
+		}
+	}
+	return nil
+}
+
+func matchZone(pattern string, zone string) bool {
+	matched, _ := path.Match(normalizeZone(pattern), zone)
+	return matched
+}
+
+// normalizeZone makes zones fully qualified, so "example.com" and "example.com." are treated the same
+func normalizeZone(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(zone, ".") + ".")
+}
+
+// isMutation reports whether the plan creates or changes the resource
+func isMutation(d *schema.ResourceDiff) bool {
+	return d.Id() == "" || len(d.GetChangedKeysPrefix("")) > 0
+}
+
+// changeBudget limits the number of record changes of a provider instance, i.e. of a single apply. It stops a broken
+// configuration before it deletes large parts of a zone
+type changeBudget struct {
+	mutex   sync.Mutex
+	max     int
+	changes int
+}
+
+func newChangeBudget(max int) *changeBudget {
+	return &changeBudget{max: max}
+}
+
+// spend counts a change and refuses it if the budget is exhausted. A nil budget or a maximum of 0 is unlimited
+func (b *changeBudget) spend(op string, subject string) error {
+	if b == nil || b.max == 0 {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.changes >= b.max {
+		return fmt.Errorf("refusing to %s %s, because %d record changes have been performed already and %s is %d",
+			op, subject, b.changes, schemaMaxRecordChanges, b.max)
+	}
+	b.changes++
+	return nil
+}
@@ -0,0 +1,65 @@
+package pinto
+
+import (
+	"context"
+	"testing"
+
+	gopinto "github.com/camaoag/project-pinto-sdk-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyRefusesMutations(t *testing.T) {
+	// without a client any request would panic, so the guard has to stop it before
+	p := &PintoProvider{readOnly: true}
+	ttl := int32(3600)
+	record := Record{
+		Record: gopinto.Record{Name: "www", Type: gopinto.RecordType("A"), Data: "127.0.0.1", Ttl: &ttl},
+		zone:   "example.com.",
+	}
+	zone := Zone{name: "example.com."}
+
+	err := createRecord(p, "", context.Background(), record)
+	require.EqualError(t, err, "refusing to create record A www in zone example.com., because the provider is configured with read_only = true")
+	err = deleteRecord(p, "", context.Background(), record)
+	require.EqualError(t, err, "refusing to delete record A www in zone example.com., because the provider is configured with read_only = true")
+	err = createZone(p, "", context.Background(), zone)
+	require.EqualError(t, err, "refusing to create zone example.com., because the provider is configured with read_only = true")
+	err = deleteZone(p, "", context.Background(), zone)
+	require.EqualError(t, err, "refusing to delete zone example.com., because the provider is configured with read_only = true")
+
+	require.NoError(t, checkWritable(&PintoProvider{}, "create", "zone example.com."))
+}
+
+func TestCheckZoneAllowed(t *testing.T) {
+	p := &PintoProvider{
+		allowedZones: []string{"*.example.com.", "example.org"},
+		deniedZones:  []string{"prod.example.com."},
+	}
+	require.NoError(t, checkZoneAllowed(p, "create", "zone staging.example.com.", "staging.example.com"))
+	require.NoError(t, checkZoneAllowed(p, "create", "zone example.org.", "Example.org."))
+
+	err := checkZoneAllowed(p, "delete", "zone prod.example.com.", "prod.example.com.")
+	require.EqualError(t, err, `refusing to delete zone prod.example.com., because zone prod.example.com. matches "prod.example.com." of denied_zones`)
+
+	err = checkZoneAllowed(p, "create", "record A www in zone example.com.", "example.com.")
+	require.EqualError(t, err, `refusing to create record A www in zone example.com., because zone example.com. does not match any pattern of allowed_zones ["*.example.com." "example.org"]`)
+
+	require.NoError(t, checkZoneAllowed(&PintoProvider{}, "create", "zone example.com.", "example.com."))
+}
+
+func TestValidateZonePatterns(t *testing.T) {
+	require.NoError(t, validateZonePatterns(schemaAllowedZones, []string{"*.example.com.", "stage-?.example.com."}))
+	require.Error(t, validateZonePatterns(schemaAllowedZones, []string{"[example.com."}))
+}
+
+func TestChangeBudget(t *testing.T) {
+	budget := newChangeBudget(2)
+	require.NoError(t, budget.spend("create", "record A www in zone example.com."))
+	require.NoError(t, budget.spend("update", "record A www in zone example.com."))
+	err := budget.spend("delete", "record A www in zone example.com.")
+	require.EqualError(t, err, "refusing to delete record A www in zone example.com., because 2 record changes have been performed already and max_record_changes is 2")
+
+	var unlimited *changeBudget
+	require.NoError(t, unlimited.spend("delete", "record A www in zone example.com."))
+	require.NoError(t, newChangeBudget(0).spend("delete", "record A www in zone example.com."))
+}
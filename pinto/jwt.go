@@ -0,0 +1,220 @@
+package pinto
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	cc "golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	clientAssertionType     = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	clientAssertionLifetime = 5 * time.Minute
+)
+
+// jwtAlgorithms are the supported signing algorithms for client assertions
+var jwtAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+type jwtSigner struct {
+	key       crypto.Signer
+	keyId     string
+	algorithm string
+	hash      crypto.Hash
+}
+
+// newJwtSigner parses a PEM encoded RSA or EC private key in PKCS#8, PKCS#1 or SEC 1 format and checks that it can
+// be used with algorithm
+func newJwtSigner(keyPem []byte, keyId string, algorithm string) (*jwtSigner, error) {
+	supported := false
+	for _, a := range jwtAlgorithms {
+		supported = supported || a == algorithm
+	}
+	if !supported {
+		return nil, fmt.Errorf("unsupported algorithm %q, expected one of %s", algorithm, strings.Join(jwtAlgorithms, ", "))
+	}
+
+	block, _ := pem.Decode(keyPem)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded private key found")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key: %v", err)
+	}
+
+	signer := &jwtSigner{
+		keyId:     keyId,
+		algorithm: algorithm,
+	}
+	switch algorithm[2:] {
+	case "256":
+		signer.hash = crypto.SHA256
+	case "384":
+		signer.hash = crypto.SHA384
+	case "512":
+		signer.hash = crypto.SHA512
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if !strings.HasPrefix(algorithm, "RS") && !strings.HasPrefix(algorithm, "PS") {
+			return nil, fmt.Errorf("algorithm %s cannot be used with an RSA key", algorithm)
+		}
+		signer.key = k
+	case *ecdsa.PrivateKey:
+		expected := map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()}[algorithm]
+		if expected == nil || k.Curve != expected {
+			return nil, fmt.Errorf("algorithm %s cannot be used with an EC key on curve %s", algorithm, k.Curve.Params().Name)
+		}
+		signer.key = k
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+func (s *jwtSigner) sign(claims map[string]interface{}) (string, error) {
+	header := map[string]string{
+		"alg": s.algorithm,
+		"typ": "JWT",
+	}
+	if s.keyId != "" {
+		header["kid"] = s.keyId
+	}
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	hasher := s.hash.New()
+	hasher.Write([]byte(signingInput))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch k := s.key.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(s.algorithm, "PS") {
+			signature, err = rsa.SignPSS(rand.Reader, k, s.hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, s.hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		var r, sig *big.Int
+		r, sig, err = ecdsa.Sign(rand.Reader, k, digest)
+		if err == nil {
+			// JWS uses the fixed-size concatenation of r and s instead of ASN.1
+			size := (k.Curve.Params().BitSize + 7) / 8
+			signature = make([]byte, 2*size)
+			rBytes, sBytes := r.Bytes(), sig.Bytes()
+			copy(signature[size-len(rBytes):size], rBytes)
+			copy(signature[2*size-len(sBytes):], sBytes)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to sign client assertion: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// decodeJwtClaims returns the claims of a JWT without verifying its signature
+func decodeJwtClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT payload: %v", err)
+	}
+	var claims map[string]interface{}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT payload: %v", err)
+	}
+	return claims, nil
+}
+
+// jwtExpiry returns the time of the "exp" claim
+func jwtExpiry(claims map[string]interface{}) (time.Time, bool) {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
+
+// jwtAssertionTokenSource requests tokens with the client-credentials grant, but authenticates the client with a
+// signed JWT (private_key_jwt, RFC 7523) instead of a client secret. Every token request uses a fresh assertion
+type jwtAssertionTokenSource struct {
+	ctx    context.Context
+	conf   cc.Config
+	signer *jwtSigner
+}
+
+func newJwtAssertionTokenSource(ctx context.Context, conf cc.Config, signer *jwtSigner) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &jwtAssertionTokenSource{
+		ctx:    ctx,
+		conf:   conf,
+		signer: signer,
+	})
+}
+
+func (s *jwtAssertionTokenSource) Token() (*oauth2.Token, error) {
+	jti := make([]byte, 16)
+	_, err := rand.Read(jti)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	assertion, err := s.signer.sign(map[string]interface{}{
+		"iss": s.conf.ClientID,
+		"sub": s.conf.ClientID,
+		"aud": s.conf.TokenURL,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifetime).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	conf := s.conf
+	conf.ClientSecret = ""
+	conf.AuthStyle = oauth2.AuthStyleInParams
+	conf.EndpointParams = url.Values{
+		"client_assertion_type": {clientAssertionType},
+		"client_assertion":      {assertion},
+	}
+	return conf.Token(s.ctx)
+}
@@ -0,0 +1,99 @@
+package pinto
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	cc "golang.org/x/oauth2/clientcredentials"
+)
+
+func TestJwtSignerRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	signer, err := newJwtSigner(keyPem, "key-1", "RS256")
+	require.NoError(t, err)
+	token, err := signer.sign(map[string]interface{}{"sub": "client"})
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"alg":"RS256","typ":"JWT","kid":"key-1"}`, string(header))
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
+
+func TestJwtSignerES256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	_, err = newJwtSigner(keyPem, "", "RS256")
+	require.Error(t, err, "an EC key cannot be used for RSA signatures")
+
+	signer, err := newJwtSigner(keyPem, "", "ES256")
+	require.NoError(t, err)
+	token, err := signer.sign(map[string]interface{}{"sub": "client"})
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.Len(t, signature, 64)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	require.True(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s))
+}
+
+func TestJwtAssertionTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	signer, err := newJwtSigner(keyPem, "", "RS256")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "client", r.PostForm.Get("client_id"))
+		require.Equal(t, "dns", r.PostForm.Get("scope"))
+		require.Equal(t, clientAssertionType, r.PostForm.Get("client_assertion_type"))
+		require.NotEmpty(t, r.PostForm.Get("client_assertion"))
+		require.Empty(t, r.PostForm.Get("client_secret"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	ts := newJwtAssertionTokenSource(context.Background(), cc.Config{
+		ClientID: "client",
+		TokenURL: server.URL,
+		Scopes:   []string{"dns"},
+	}, signer)
+	token, err := ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token", token.AccessToken)
+}
@@ -0,0 +1,149 @@
+package pinto
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	defaultSharedConfigFile      = "~/.pinto/config"
+	defaultSharedCredentialsFile = "~/.pinto/credentials"
+)
+
+// profileKeys are the provider settings which can be loaded from a named profile
+var profileKeys = []string{
+	schemaBaseUrl,
+	schemaTokenUrl,
+	schemaIssuerUrl,
+	schemaClientId,
+	schemaClientSecret,
+	schemaClientScope,
+	schemaCredentialsId,
+	schemaProvider,
+	schemaEnvironment,
+	schemaCredentialProcess,
+}
+
+type profile map[string]string
+
+// providerSettings resolves provider settings. Values set in the provider configuration or via PINTO_* environment
+// variables take precedence over the values of the selected profile
+type providerSettings struct {
+	d       *schema.ResourceData
+	profile profile
+}
+
+func (s providerSettings) GetOk(key string) (string, bool) {
+	v, ok := s.d.GetOk(key)
+	if ok {
+		return v.(string), true
+	}
+	p, ok := s.profile[key]
+	return p, ok && p != ""
+}
+
+func (s providerSettings) Get(key string) string {
+	v, _ := s.GetOk(key)
+	return v
+}
+
+// loadProviderSettings reads the profile selected by the provider configuration, if any
+func loadProviderSettings(d *schema.ResourceData) (providerSettings, error) {
+	settings := providerSettings{
+		d:       d,
+		profile: profile{},
+	}
+	name, ok := d.GetOk(schemaProfile)
+	if !ok {
+		return settings, nil
+	}
+
+	configFile := d.Get(schemaSharedConfigFile).(string)
+	credentialsFile := d.Get(schemaSharedCredentialsFile).(string)
+	found := false
+	// secrets from the credentials file win over the same keys in the config file, like with the AWS CLI
+	for _, file := range []string{configFile, credentialsFile} {
+		p, ok, err := readProfile(file, name.(string))
+		if err != nil {
+			return settings, err
+		}
+		if !ok {
+			continue
+		}
+		found = true
+		for k, v := range p {
+			settings.profile[k] = v
+		}
+	}
+	if !found {
+		return settings, fmt.Errorf("profile %q not found in %s or %s", name, configFile, credentialsFile)
+	}
+	log.Printf("[DEBUG] Pinto: Using profile %s", name)
+	return settings, nil
+}
+
+// readProfile reads a profile from an ini-style file. Sections may be named "[name]" or "[profile name]".
+// A missing file is not an error, because profiles can be split between the config and the credentials file
+func readProfile(file string, name string) (profile, bool, error) {
+	path, err := expandPath(file)
+	if err != nil {
+		return nil, false, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read %s: %v", path, err)
+	}
+	defer f.Close()
+
+	p := profile{}
+	found := false
+	inProfile := false
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
+			section = strings.TrimSpace(strings.TrimPrefix(section, "profile "))
+			inProfile = section == name
+			found = found || inProfile
+			continue
+		}
+		if !inProfile {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, false, fmt.Errorf("invalid line %d in %s: expected \"key = value\"", lineNumber, path)
+		}
+		key := strings.TrimSpace(kv[0])
+		if !isProfileKey(key) {
+			log.Printf("[WARN] Pinto: Ignoring unknown key %s of profile %s in %s", key, name, path)
+			continue
+		}
+		p[key] = strings.TrimSpace(kv[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, fmt.Errorf("unable to read %s: %v", path, err)
+	}
+	return p, found, nil
+}
+
+func isProfileKey(key string) bool {
+	for _, k := range profileKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
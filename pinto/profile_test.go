@@ -0,0 +1,64 @@
+package pinto
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProviderSettingsFromProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinto-profile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "config")
+	credentialsFile := filepath.Join(dir, "credentials")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(`
+[default]
+base_url = https://default.mock.co
+
+[profile test]
+# the test stack
+base_url          = https://test.mock.co
+token_url         = https://auth.test.mock.co/connect/token
+pinto_provider    = digitalocean
+pinto_environment = prod1
+`), 0600))
+	require.NoError(t, ioutil.WriteFile(credentialsFile, []byte(`
+[test]
+client_id      = client
+client_secret  = secret
+credentials_id = 4d4fe4ac-586e-4121-9603-43acf2b0ce8d
+`), 0600))
+
+	d := schema.TestResourceDataRaw(t, Provider(nil).Schema, map[string]interface{}{
+		schemaProfile:               "test",
+		schemaSharedConfigFile:      configFile,
+		schemaSharedCredentialsFile: credentialsFile,
+		schemaEnvironment:           "prod2",
+	})
+	settings, err := loadProviderSettings(d)
+	require.NoError(t, err)
+
+	require.Equal(t, "https://test.mock.co", settings.Get(schemaBaseUrl))
+	require.Equal(t, "secret", settings.Get(schemaClientSecret))
+	require.Equal(t, "4d4fe4ac-586e-4121-9603-43acf2b0ce8d", settings.Get(schemaCredentialsId))
+	require.Equal(t, "digitalocean", settings.Get(schemaProvider))
+	require.Equal(t, "prod2", settings.Get(schemaEnvironment), "explicit values take precedence over the profile")
+	_, ok := settings.GetOk(schemaClientScope)
+	require.False(t, ok)
+}
+
+func TestLoadProviderSettingsUnknownProfile(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider(nil).Schema, map[string]interface{}{
+		schemaProfile:               "unknown",
+		schemaSharedConfigFile:      "/nonexistent/config",
+		schemaSharedCredentialsFile: "/nonexistent/credentials",
+	})
+	_, err := loadProviderSettings(d)
+	require.EqualError(t, err, `profile "unknown" not found in /nonexistent/config or /nonexistent/credentials`)
+}
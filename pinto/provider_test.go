@@ -5,13 +5,16 @@ import (
 	"context"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
+	"sync"
 	"testing"
 
 	gopinto "github.com/camaoag/project-pinto-sdk-go"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/meta"
 
 	"github.com/stretchr/testify/require"
 )
@@ -95,11 +98,39 @@ func NewMockClient(recordsApi gopinto.RecordsApi, zonesApi gopinto.ZonesApi) *mo
 	}
 }
 
+// xApiOptionsRecorder answers every request of a client with an empty JSON object and records its X-Api-Options
+type xApiOptionsRecorder struct {
+	mutex   sync.Mutex
+	headers []string
+}
+
+func newXApiOptionsRecorder(t *testing.T) (*gopinto.APIClient, *xApiOptionsRecorder) {
+	recorder := &xApiOptionsRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder.mutex.Lock()
+		recorder.headers = append(recorder.headers, r.Header.Get("X-Api-Options"))
+		recorder.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	conf := gopinto.NewConfiguration()
+	conf.Servers[0].URL = server.URL
+	return gopinto.NewAPIClient(conf), recorder
+}
+
+func (r *xApiOptionsRecorder) recorded() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.headers...)
+}
+
 // unit tests to validate that the provider implements the expected resources
 func TestProvider_HasNeededResources(t *testing.T) {
 	expectedResources := []string{
 		"pinto_dns_zone",
 		"pinto_dns_record",
+		"pinto_dns_record_set",
 	}
 
 	resources := Provider(nil).ResourcesMap
@@ -118,6 +149,7 @@ func TestProvider_HasNeededDatasources(t *testing.T) {
 		"pinto_dns_zones",
 		"pinto_dns_record",
 		"pinto_dns_records",
+		"pinto_caller_identity",
 	}
 
 	provider := Provider(nil)
@@ -604,3 +636,10 @@ func (m mockRecordsChangeApiService) DnsApiRecordsPostExecute(r gopinto.ApiDnsAp
 func toInt32(x int32) *int32 {
 	return &x
 }
+
+func TestUserAgent(t *testing.T) {
+	require.Equal(t, "terraform-provider-pinto/1.2.0 terraform/1.0.5 terraform-plugin-sdk/"+meta.SDKVersionString(),
+		userAgent("1.2.0", "1.0.5", ""))
+	require.Equal(t, "terraform-provider-pinto/dev terraform/unknown terraform-plugin-sdk/"+meta.SDKVersionString()+" team-dns",
+		userAgent("dev", "", "team-dns"))
+}
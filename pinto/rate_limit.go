@@ -0,0 +1,163 @@
+package pinto
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// after a throttling response without Retry-After all requests are paused for this duration
+	defaultThrottlePause = time.Second
+	// the rate is never reduced below this fraction of requests_per_second
+	minRateFraction = 0.1
+	// every successful request recovers this fraction of requests_per_second
+	rateRecoveryFraction = 0.05
+)
+
+// rateLimiter is shared by all requests of a provider instance. It limits the requests per second and the number of
+// requests in flight. When the server throttles, the rate is halved and all requests are paused, afterwards the rate
+// recovers slowly with every successful request
+type rateLimiter struct {
+	mutex       sync.Mutex
+	maxRate     float64
+	rate        float64
+	next        time.Time
+	pausedUntil time.Time
+
+	// slots is nil if the number of concurrent requests is unlimited
+	slots chan struct{}
+}
+
+// newRateLimiter creates a limiter allowing requestsPerSecond and maxConcurrent requests. Zero disables the respective
+// limit
+func newRateLimiter(requestsPerSecond float64, maxConcurrent int) *rateLimiter {
+	l := &rateLimiter{
+		maxRate: requestsPerSecond,
+		rate:    requestsPerSecond,
+	}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// reserve returns how long the caller has to wait before its request may be sent
+func (l *rateLimiter) reserve() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now()
+	start := now
+	if l.pausedUntil.After(start) {
+		start = l.pausedUntil
+	}
+	if l.rate > 0 {
+		if l.next.After(start) {
+			start = l.next
+		}
+		l.next = start.Add(time.Duration(float64(time.Second) / l.rate))
+	}
+	return start.Sub(now)
+}
+
+// wait blocks until a request may be sent and a slot is available. The returned function releases the slot
+func (l *rateLimiter) wait(req *http.Request) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	delay := l.reserve()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			release()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	return release, nil
+}
+
+// throttled slows down all requests after the server responded with 429
+func (l *rateLimiter) throttled(host string, resp *http.Response) {
+	pause, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+	if !ok {
+		pause = defaultThrottlePause
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	until := time.Now().Add(pause)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	log.Printf("[WARN] Pinto: Throttled by %s, pausing requests for %s", host, pause)
+	if l.maxRate > 0 {
+		l.rate = l.rate / 2
+		if l.rate < l.maxRate*minRateFraction {
+			l.rate = l.maxRate * minRateFraction
+		}
+		log.Printf("[WARN] Pinto: Reducing the rate to %.2f requests per second", l.rate)
+	}
+}
+
+func (l *rateLimiter) succeeded() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.rate < l.maxRate {
+		l.rate += l.maxRate * rateRecoveryFraction
+		if l.rate > l.maxRate {
+			l.rate = l.maxRate
+		}
+	}
+}
+
+// rateLimitTransport sends every request through the limiter. A slot is held until the response body is closed
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.wait(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.limiter.throttled(req.URL.Host, resp)
+	} else if resp.StatusCode < http.StatusInternalServerError {
+		t.limiter.succeeded()
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
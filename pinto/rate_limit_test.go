@@ -0,0 +1,74 @@
+package pinto
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	limiter := newRateLimiter(10, 0)
+	require.Equal(t, time.Duration(0), limiter.reserve())
+	second := limiter.reserve()
+	require.True(t, second > 90*time.Millisecond && second <= 100*time.Millisecond, second.String())
+
+	unlimited := newRateLimiter(0, 0)
+	require.Equal(t, time.Duration(0), unlimited.reserve())
+	require.Equal(t, time.Duration(0), unlimited.reserve())
+}
+
+func TestRateLimiterSlowsDownWhenThrottled(t *testing.T) {
+	limiter := newRateLimiter(10, 0)
+	resp := &http.Response{Header: http.Header{"Retry-After": {"2"}}}
+	limiter.throttled("pinto.mock.co", resp)
+	require.Equal(t, 5.0, limiter.rate)
+	require.True(t, limiter.reserve() > time.Second, "requests should be paused")
+
+	for i := 0; i < 5; i++ {
+		limiter.throttled("pinto.mock.co", resp)
+	}
+	require.Equal(t, 1.0, limiter.rate)
+
+	for i := 0; i < 100; i++ {
+		limiter.succeeded()
+	}
+	require.Equal(t, 10.0, limiter.rate)
+}
+
+func TestRateLimitTransportLimitsConcurrency(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, limiter: newRateLimiter(0, 2)}}
+
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		require.NoError(t, <-errs)
+	}
+	require.LessOrEqual(t, maxInFlight, 2)
+}
@@ -2,17 +2,24 @@ package pinto
 
 import (
 	"context"
-	"crypto/sha1"
-	"encoding/hex"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"time"
 
 	gopinto "github.com/camaoag/project-pinto-sdk-go"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// resourceDnsRecordSchemaVersion 1 replaced the SHA-1 hash of the record by the import format as id
+const resourceDnsRecordSchemaVersion = 1
+
+// restoreTimeout limits restoring the records of a set after a failed change
+const restoreTimeout = 2 * time.Minute
+
 func resourceDnsRecord() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceDnsRecordCreate,
@@ -22,6 +29,26 @@ func resourceDnsRecord() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceDnsRecordImport,
 		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffBackend,
+			customizeDiffReadOnly,
+			customizeDiffZoneRules("zone"),
+			customizeDiffRecordDefaults,
+		),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		SchemaVersion: resourceDnsRecordSchemaVersion,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceDnsRecordV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceDnsRecordStateUpgradeV0,
+			},
+		},
 		Schema: map[string]*schema.Schema{
 			schemaProvider: {
 				Type:     schema.TypeString,
@@ -31,6 +58,17 @@ func resourceDnsRecord() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			// a changed backend or tenant must not receive the delete of the old object, so it is replaced
+			schemaBackend: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The backend the record has been created in, as `{environment}/{provider}`.",
+			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 			"zone": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -46,11 +84,12 @@ func resourceDnsRecord() *schema.Resource {
 			"class": {
 				Type:     schema.TypeString,
 				Optional: true,
-				Default:  "IN",
+				Computed: true,
 			},
 			"ttl": {
 				Type:     schema.TypeInt,
 				Optional: true,
+				Computed: true,
 			},
 			"data": {
 				Type:     schema.TypeString,
@@ -67,6 +106,12 @@ type Record struct {
 	id          string
 	environment string
 	provider    string
+	// only set if the credentials id is overridden on resource-level
+	credentialsId string
+}
+
+func describeRecord(record Record) string {
+	return fmt.Sprintf("record %s %s in zone %s", record.Type, record.Name, record.zone)
 }
 
 func printDebugRecord(record Record) {
@@ -74,11 +119,145 @@ func printDebugRecord(record Record) {
 		record.id, record.Name, record.zone, record.Class, record.Data, record.Type)
 }
 
+// computeRecordId returns the id of a record in the import format "{type}/{name}/{zone}/{environment}/{provider}".
+// The data is not part of the id, so the id stays stable if only the data is updated
 func computeRecordId(record Record) string {
-	idString := record.Data + "-" + string(record.Type) + "." + record.Name + "." + record.zone + record.environment + "." + record.provider + "."
-	h := sha1.New()
-	h.Write([]byte(idString))
-	return hex.EncodeToString(h.Sum(nil))
+	return strings.Join([]string{
+		string(record.Type),
+		record.Name,
+		record.zone,
+		record.environment,
+		providerIdSegment(record.provider, record.credentialsId),
+	}, "/")
+}
+
+// recordIdBackend returns the pinto_backend of a record id
+func recordIdBackend(id string) (string, bool) {
+	in := strings.Split(id, "/")
+	if len(in) < 5 {
+		return "", false
+	}
+	provider, _ := splitProviderIdSegment(in[4])
+	return formatBackend(provider, in[3]), true
+}
+
+// computeSharedRecordId returns the id of a record whose set has further values. The data is appended as in the
+// import format, so the records of a set get different ids which can be imported again
+func computeSharedRecordId(record Record) string {
+	return computeRecordId(record) + "/" + record.Data
+}
+
+// isSharedRecordId reports whether an id contains the data of the record, because its set had further values
+func isSharedRecordId(id string) bool {
+	return len(strings.Split(id, "/")) > 5
+}
+
+// resourceDnsRecordV0 is the schema of version 0, whose ids are a SHA-1 hash of the record
+func resourceDnsRecordV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			schemaProvider: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			schemaEnvironment: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"class": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"ttl": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+			"data": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+		},
+	}
+}
+
+// resourceDnsRecordStateUpgradeV0 replaces the hashed id by the readable one. The hash cannot be reversed, so the id
+// is computed from the attributes, with the provider and environment of the provider if they are not set on the record.
+// Whether the set has further values is unknown, so the data is part of the id until the next read
+func resourceDnsRecordStateUpgradeV0(_ context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	pinto, ok := meta.(*PintoProvider)
+	if !ok {
+		return nil, fmt.Errorf("unable to upgrade the state of pinto_dns_record %v: the provider is not configured", rawState["id"])
+	}
+	attribute := func(key string) string {
+		value, _ := rawState[key].(string)
+		return value
+	}
+
+	var record Record
+	record.Type = gopinto.RecordType(attribute("type"))
+	record.Name = attribute("name")
+	record.zone = attribute("zone")
+	record.environment = attribute(schemaEnvironment)
+	if record.environment == "" {
+		record.environment = pinto.environment
+	}
+	record.provider = attribute(schemaProvider)
+	if record.provider == "" {
+		record.provider = pinto.provider
+	}
+	if record.provider == "" {
+		return nil, fmt.Errorf("unable to upgrade the state of pinto_dns_record %v: %s has to be set on provider or resource-level",
+			rawState["id"], schemaProvider)
+	}
+	record.credentialsId = attribute(schemaCredentialsId)
+	record.Data = attribute("data")
+
+	id := computeSharedRecordId(record)
+	log.Printf("[INFO] Pinto: Upgrading id of pinto_dns_record from %v to %s", rawState["id"], id)
+	rawState["id"] = id
+	return rawState, nil
+}
+
+// customizeDiffRecordDefaults plans the record_defaults of the provider for ttl and class, if they are not configured.
+// An unset attribute is only unknown when the record is created, afterwards the value of the state is kept. Note that
+// a configured value which is unknown during plan cannot be told apart from an unset one, which is documented as a
+// limitation of the resources
+func customizeDiffRecordDefaults(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	p, ok := m.(*PintoProvider)
+	if !ok || d.Id() != "" {
+		return nil
+	}
+	if !d.NewValueKnown("ttl") {
+		err := d.SetNew("ttl", p.recordTtl)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.NewValueKnown("class") {
+		err := d.SetNew("class", p.recordClass)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func dataToRecord(d *schema.ResourceData, provider *PintoProvider) (Record, error) {
@@ -90,6 +269,7 @@ func dataToRecord(d *schema.ResourceData, provider *PintoProvider) (Record, erro
 		record.provider = s
 	}
 	record.environment = getEnvironment(provider, d)
+	record.credentialsId = getResourceCredentialsId(d)
 	record.zone = d.Get("zone").(string)
 	record.Name = d.Get("name").(string)
 	record.Type = gopinto.RecordType(d.Get("type").(string))
@@ -103,15 +283,21 @@ func dataToRecord(d *schema.ResourceData, provider *PintoProvider) (Record, erro
 	return record, nil
 }
 
-func createRecord(client *gopinto.APIClient, xApiOptions string, ctx context.Context, record Record) error {
+func createRecord(p *PintoProvider, xApiOptions string, ctx context.Context, record Record) error {
+	if err := checkWritable(p, "create", describeRecord(record)); err != nil {
+		return err
+	}
+	if err := checkZoneAllowed(p, "create", describeRecord(record), record.zone); err != nil {
+		return err
+	}
 	log.Printf("[DEBUG] Pinto: Creating Record:")
 	printDebugRecord(record)
-	log.Printf("[DEBUG] Pinto: Using: %v", xApiOptions)
+	log.Printf("[DEBUG] Pinto: Using: %v", redactJson([]byte(xApiOptions)))
 	crr := gopinto.NewCreateRecordRequestModel(record.zone, record.Name, record.Type, record.Data)
 
 	crr.SetClass(gopinto.RecordClass(record.Class))
 	crr.SetTtl(int32(*record.Ttl))
-	_, resp, gErr := client.RecordsApi.
+	_, resp, gErr := p.client.RecordsApi.
 		DnsApiRecordsPost(ctx).
 		CreateRecordRequestModel(*crr).
 		XApiOptions(xApiOptions).
@@ -142,22 +328,48 @@ func resourceDnsRecordCreate(ctx context.Context, d *schema.ResourceData, m inte
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	record.id = computeRecordId(record)
-	log.Printf("[INFO] Pinto: Creating record %s in environment %s of provider %s", record.id, pinto.environment, pinto.provider)
+	log.Printf("[INFO] Pinto: Creating record %s in environment %s of provider %s", record.id, record.environment, record.provider)
+	err = pinto.changeBudget.spend("create", describeRecord(record))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	if !record.HasTtl() {
-		// if no TTL is set, then we use the default value 3600
-		ttl32 := int32(3600)
+		// if no TTL is planned, then we use the default of the provider
+		ttl32 := int32(pinto.recordTtl)
 		record.Ttl = &ttl32
 	}
-	err = createRecord(pinto.client, pinto.xApiOptions, pctx, record)
+	if record.Class == "" {
+		record.Class = gopinto.RecordClass(pinto.recordClass)
+	}
+	records, err := readRecordSet(pctx, pinto, xApiOptions, record)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	if len(recordSiblings(pinto, record, records)) > 0 {
+		record.id = computeSharedRecordId(record)
+	}
+	err = createRecord(pinto, xApiOptions, pctx, record)
+	if err != nil {
+		return diag.FromErr(annotateTimeout(pctx, "creating", describeRecord(record), err))
+	}
 
 	err = d.Set("ttl", *record.Ttl)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	err = d.Set("class", string(record.Class))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set(schemaBackend, formatBackend(record.provider, record.environment))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	d.SetId(record.id)
 
 	return diags
@@ -173,42 +385,107 @@ func resourceDnsRecordRead(ctx context.Context, d *schema.ResourceData, m interf
 		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
 	}
 
+	// states written before pinto_backend existed take the backend from their id
+	if _, ok := d.GetOk(schemaBackend); !ok {
+		if backend, ok := recordIdBackend(d.Id()); ok {
+			err := d.Set(schemaBackend, backend)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
 	record, err := dataToRecord(d, pinto)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	log.Printf("[INFO] Pinto: Reading information for record with name %s in environment %s of provider %s", record.Name+"."+record.zone,
-		pinto.environment, pinto.provider)
+		record.environment, record.provider)
 	log.Printf("[DEBUG] Pinto: Reading Record:")
 	printDebugRecord(record)
 	request := pinto.client.RecordsApi.
-		DnsApiRecordsGet(ctx).
+		DnsApiRecordsGet(pctx).
 		Name(record.Name).
 		RecordType(record.Type).
-		XApiOptions(pinto.xApiOptions).
+		XApiOptions(xApiOptions).
 		Zone(record.zone)
 
 	r, resp, gErr := request.Execute()
 
-	if resp.StatusCode >= 400 {
-		return diag.Errorf(handleClientError("RECORD READ", gErr.Error(), resp))
+	if resp == nil || resp.StatusCode >= 400 {
+		err = fmt.Errorf(handleClientError("RECORD READ", gErr.Error(), resp))
+		return diag.FromErr(annotateTimeout(pctx, "reading", describeRecord(record), err))
 	}
 	record.id = computeRecordId(record)
-	if len(r) == 0 {
+	current, ok := findRecord(r, record.Data, !isSharedRecordId(d.Id()))
+	if !ok {
 		log.Printf("[WARN] Pinto: Could not retrieve information for pinto_dns_record with id %s. Removing it from state", record.id)
 		d.SetId("")
-	} else {
-		err := d.Set("ttl", *r[0].Ttl)
+		return diags
+	}
+	// the data of the state is kept if it only differs in notation, so it does not cause a diff
+	if !recordDataEqual(current.Data, record.Data) {
+		log.Printf("[WARN] Pinto: Data of %s changed outside of Terraform from %s to %s", describeRecord(record), record.Data, current.Data)
+		record.Data = current.Data
+		err = d.Set("data", current.Data)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if len(r) > 1 {
+		record.id = computeSharedRecordId(record)
+	}
+	if current.Class != "" {
+		err = d.Set("class", string(current.Class))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if current.HasTtl() {
+		err = d.Set("ttl", *current.Ttl)
 		if err != nil {
 			return diag.FromErr(err)
 		}
-		d.SetId(record.id)
 	}
+	err = d.Set(schemaBackend, formatBackend(record.provider, record.environment))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(record.id)
 
 	return diags
 }
 
-func deleteRecord(client *gopinto.APIClient, xApiOptions string, ctx context.Context, record Record) error {
+// findRecord returns the record of a set whose data matches data. If no record matches, the set consists of a single
+// record and the set had no further values before (sole), its data has been changed outside of Terraform and that
+// record is returned. Otherwise the record may be managed by another resource, so none is returned
+func findRecord(records []gopinto.Record, data string, sole bool) (gopinto.Record, bool) {
+	for _, r := range records {
+		if recordDataEqual(r.Data, data) {
+			return r, true
+		}
+	}
+	if sole && len(records) == 1 {
+		return records[0], true
+	}
+	return gopinto.Record{}, false
+}
+
+// recordDataEqual ignores a trailing dot, because Pinto may return host names fully qualified
+func recordDataEqual(a string, b string) bool {
+	return a == b || strings.TrimSuffix(a, ".") == strings.TrimSuffix(b, ".")
+}
+
+func deleteRecord(p *PintoProvider, xApiOptions string, ctx context.Context, record Record) error {
+	if err := checkWritable(p, "delete", describeRecord(record)); err != nil {
+		return err
+	}
+	if err := checkZoneAllowed(p, "delete", describeRecord(record), record.zone); err != nil {
+		return err
+	}
 	log.Printf("[INFO] Pinto: Deleting record with id %s in environment %s of provider %s", record.id, record.environment, record.provider)
 	log.Printf("[DEBUG] Pinto: Working in env %s of pinto %s", record.environment, record.provider)
 	log.Printf("[DEBUG] Pinto: Deleting Record:")
@@ -217,7 +494,7 @@ func deleteRecord(client *gopinto.APIClient, xApiOptions string, ctx context.Con
 	// TODO: Clarify request body
 	// rBody := make(map[string]string)
 
-	request := client.RecordsApi.
+	request := p.client.RecordsApi.
 		DnsApiRecordsDelete(ctx).
 		Zone(record.zone).
 		Name(record.Name).
@@ -245,11 +522,42 @@ func resourceDnsRecordDelete(ctx context.Context, d *schema.ResourceData, m inte
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	record.id = d.Id()
-	err = deleteRecord(pinto.client, pinto.xApiOptions, pctx, record)
+	err = pinto.changeBudget.spend("delete", describeRecord(record))
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	// Pinto deletes the whole set, so its other records, which may be managed by other resources, are recreated
+	records, err := readRecordSet(pctx, pinto, xApiOptions, record)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	siblings := recordSiblings(pinto, record, records)
+	for _, sibling := range siblings {
+		err = pinto.changeBudget.spend("update", describeRecord(sibling)+" with data "+strconv.Quote(sibling.Data))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	err = deleteRecord(pinto, xApiOptions, pctx, record)
+	if err != nil {
+		return diag.FromErr(annotateTimeout(pctx, "deleting", describeRecord(record), err))
+	}
+	failed, restoreErr := restoreRecords(pinto, xApiOptions, siblings)
+	if len(failed) > 0 {
+		// the record itself is gone, so it is removed from state nevertheless
+		d.SetId("")
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to restore the other records of the set of " + describeRecord(record),
+			Detail: fmt.Sprintf("The record has been deleted, but Pinto deleted the whole set and the records with the data %s "+
+				"could not be recreated: %v", listRecordData(failed), restoreErr),
+		}}
+	}
 
 	return diags
 }
@@ -265,7 +573,6 @@ func buildRecordsFromChange(p *PintoProvider, d *schema.ResourceData) (Record, R
 		return r, r, err
 	}
 	oldRecord := r
-	newRecord.id = d.Id()
 	oldRecord.id = d.Id()
 	if d.HasChange("name") {
 		o, n := d.GetChange("name")
@@ -299,36 +606,249 @@ func buildRecordsFromChange(p *PintoProvider, d *schema.ResourceData) (Record, R
 		oldRecord.Data = o.(string)
 		newRecord.Data = n.(string)
 	}
+	newRecord.id = computeRecordId(newRecord)
 
 	return oldRecord, newRecord, nil
 }
 
 func resourceDnsRecordUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	pinto := m.(*PintoProvider)
-	// Warning or errors can be collected in a slice type
-	var diags diag.Diagnostics
 
 	pctx := ctx
 	if pinto.apiKey != "" {
 		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
 	}
 
-	log.Printf("[INFO] Pinto: Updating record with id %s in environment %s of provider %s", d.Id(), pinto.environment, pinto.provider)
-	//TODO: pinto api does not support an update of Records at the moment; instead we have to delete and create the Record
+	// the provider and environment may be set on the resource without changing its backend, which needs no request
+	if !d.HasChanges("zone", "name", "type", "class", "ttl", "data") {
+		return nil
+	}
+	// pinto api does not support an update of Records at the moment; instead we have to delete and create the Record
 	oldRecord, newRecord, err := buildRecordsFromChange(pinto, d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = deleteRecord(pinto.client, pinto.xApiOptions, pctx, oldRecord)
+	xApiOptions, err := getXApiOptions(pinto, d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = createRecord(pinto.client, pinto.xApiOptions, pctx, newRecord)
+	log.Printf("[INFO] Pinto: Updating record with id %s in environment %s of provider %s", d.Id(), newRecord.environment, newRecord.provider)
+	err = pinto.changeBudget.spend("update", describeRecord(oldRecord))
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	for _, r := range []*Record{&oldRecord, &newRecord} {
+		if !r.HasTtl() {
+			ttl32 := int32(pinto.recordTtl)
+			r.Ttl = &ttl32
+		}
+	}
 
-	return diags
+	// errors keep the old values in state, unless the new record has been created already
+	d.Partial(true)
+	if recordsCanCoexist(oldRecord, newRecord) {
+		return updateRecordCreateFirst(pctx, d, pinto, xApiOptions, oldRecord, newRecord)
+	}
+	return updateRecordDeleteFirst(pctx, d, pinto, xApiOptions, oldRecord, newRecord)
+}
+
+// sameRRset reports whether both records belong to the same set of records. DNS names are case-insensitive
+func sameRRset(a Record, b Record) bool {
+	return sameName(a, b) && a.Type == b.Type
+}
+
+func sameName(a Record, b Record) bool {
+	return normalizeZone(a.zone) == normalizeZone(b.zone) && strings.EqualFold(a.Name, b.Name)
+}
+
+// recordsCanCoexist reports whether the new record can be created before the old one is deleted. Pinto deletes whole
+// sets only, so records of the same set cannot, and a CNAME cannot coexist with any other record of its name
+func recordsCanCoexist(oldRecord Record, newRecord Record) bool {
+	if !sameName(oldRecord, newRecord) {
+		return true
+	}
+	return oldRecord.Type != newRecord.Type && oldRecord.Type != gopinto.CNAME && newRecord.Type != gopinto.CNAME
+}
+
+// updateRecordCreateFirst creates the new record before deleting the old one, so the name keeps resolving
+func updateRecordCreateFirst(ctx context.Context, d *schema.ResourceData, p *PintoProvider, xApiOptions string, oldRecord Record, newRecord Record) diag.Diagnostics {
+	// Pinto deletes the whole set of the old record, so its other records have to be recreated afterwards
+	records, err := readRecordSet(ctx, p, xApiOptions, oldRecord)
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecord(oldRecord),
+			Detail:   fmt.Sprintf("Reading the records of the set failed, the record is unchanged: %v", err),
+		}}
+	}
+	siblings := recordSiblings(p, oldRecord, records)
+	for _, sibling := range siblings {
+		err = p.changeBudget.spend("update", describeRecord(sibling)+" with data "+strconv.Quote(sibling.Data))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	records, err = readRecordSet(ctx, p, xApiOptions, newRecord)
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecord(oldRecord),
+			Detail:   fmt.Sprintf("Reading the records of the new set failed, the record is unchanged: %v", err),
+		}}
+	}
+	if len(recordSiblings(p, newRecord, records)) > 0 {
+		newRecord.id = computeSharedRecordId(newRecord)
+	}
+
+	err = createRecord(p, xApiOptions, ctx, newRecord)
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecord(oldRecord),
+			Detail: fmt.Sprintf("Creating the new %s failed, the old record is unchanged: %v", describeRecord(newRecord),
+				annotateTimeout(ctx, "creating", describeRecord(newRecord), err)),
+		}}
+	}
+	// from now on the state has to point to the new record
+	d.Partial(false)
+	d.SetId(newRecord.id)
+	err = deleteRecord(p, xApiOptions, ctx, oldRecord)
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to delete old " + describeRecord(oldRecord),
+			Detail: fmt.Sprintf("The new %s has been created, but the old record still exists and has to be deleted manually: %v",
+				describeRecord(newRecord), annotateTimeout(ctx, "deleting", describeRecord(oldRecord), err)),
+		}}
+	}
+	failed, restoreErr := restoreRecords(p, xApiOptions, siblings)
+	if len(failed) > 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to restore the other records of the set of " + describeRecord(oldRecord),
+			Detail: fmt.Sprintf("The record has been updated, but Pinto deleted the whole old set and the records with the data %s "+
+				"could not be recreated: %v", listRecordData(failed), restoreErr),
+		}}
+	}
+	return nil
+}
+
+// updateRecordDeleteFirst replaces a record of the same set. Pinto deletes whole sets only, so the other records of the
+// set, which may be managed by other resources, are recreated as well. If the new record cannot be created, the old
+// records are restored
+func updateRecordDeleteFirst(ctx context.Context, d *schema.ResourceData, p *PintoProvider, xApiOptions string, oldRecord Record, newRecord Record) diag.Diagnostics {
+	records, err := readRecordSet(ctx, p, xApiOptions, oldRecord)
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecord(oldRecord),
+			Detail:   fmt.Sprintf("Reading the records of the set failed, the record is unchanged: %v", err),
+		}}
+	}
+	siblings := recordSiblings(p, oldRecord, records)
+	for _, sibling := range siblings {
+		err = p.changeBudget.spend("update", describeRecord(sibling)+" with data "+strconv.Quote(sibling.Data))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	err = deleteRecord(p, xApiOptions, ctx, oldRecord)
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecord(oldRecord),
+			Detail: fmt.Sprintf("Deleting the old record failed, the record is unchanged: %v",
+				annotateTimeout(ctx, "deleting", describeRecord(oldRecord), err)),
+		}}
+	}
+	if len(siblings) > 0 && sameRRset(oldRecord, newRecord) {
+		newRecord.id = computeSharedRecordId(newRecord)
+	}
+	err = createRecord(p, xApiOptions, ctx, newRecord)
+	if err == nil {
+		d.Partial(false)
+		d.SetId(newRecord.id)
+		failed, restoreErr := restoreRecords(p, xApiOptions, siblings)
+		if len(failed) > 0 {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "Unable to restore the other records of the set of " + describeRecord(newRecord),
+				Detail: fmt.Sprintf("The record has been updated, but Pinto deleted the whole set and the records with the data %s "+
+					"could not be recreated: %v", listRecordData(failed), restoreErr),
+			}}
+		}
+		return nil
+	}
+	createErr := annotateTimeout(ctx, "creating", describeRecord(newRecord), err)
+
+	log.Printf("[WARN] Pinto: Unable to create updated %s, restoring the old records of the set: %v", describeRecord(newRecord), createErr)
+	failed, restoreErr := restoreRecords(p, xApiOptions, append([]Record{oldRecord}, siblings...))
+	if len(failed) > 0 && failed[0].Data == oldRecord.Data {
+		// the record is gone, so it is removed from state and the next plan creates it again
+		d.SetId("")
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecord(oldRecord) + ", the record has been deleted",
+			Detail: fmt.Sprintf("Creating the new record failed: %v\nRestoring the records with the data %s failed too: %v",
+				createErr, listRecordData(failed), restoreErr),
+		}}
+	}
+	if len(failed) > 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecord(oldRecord),
+			Detail: fmt.Sprintf("Creating the new record failed, the old record has been restored: %v\n"+
+				"Restoring the other records of the set with the data %s failed: %v", createErr, listRecordData(failed), restoreErr),
+		}}
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Unable to update " + describeRecord(oldRecord),
+		Detail:   fmt.Sprintf("Creating the new record failed, the old record has been restored: %v", createErr),
+	}}
+}
+
+// recordSiblings returns the records of a set except the given one. Missing ttls and classes are taken from the
+// record_defaults of the provider
+func recordSiblings(p *PintoProvider, record Record, records []gopinto.Record) []Record {
+	var siblings []Record
+	skipped := false
+	for _, r := range records {
+		if !skipped && recordDataEqual(r.Data, record.Data) {
+			skipped = true
+			continue
+		}
+		sibling := record
+		sibling.id = ""
+		sibling.Data = r.Data
+		sibling.Class = r.Class
+		sibling.Ttl = r.Ttl
+		applyRecordSetDefaults(p, &sibling)
+		siblings = append(siblings, sibling)
+	}
+	return siblings
+}
+
+// restoreRecords creates all records and returns the ones which could not be created together with the last error.
+// The context of the change may have expired already, e.g. if the failed create timed out, so the records are restored
+// with a deadline of their own
+func restoreRecords(p *PintoProvider, xApiOptions string, records []Record) ([]gopinto.Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+	defer cancel()
+	if p.apiKey != "" {
+		ctx = context.WithValue(ctx, gopinto.ContextAPIKeys, p.apiKey)
+	}
+	var failed []gopinto.Record
+	var lastErr error
+	for _, record := range records {
+		err := createRecord(p, xApiOptions, ctx, record)
+		if err != nil {
+			failed = append(failed, record.Record)
+			lastErr = annotateTimeout(ctx, "restoring", describeRecord(record), err)
+		}
+	}
+	return failed, lastErr
 }
 
 func resourceDnsRecordImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
@@ -339,10 +859,14 @@ func resourceDnsRecordImport(ctx context.Context, d *schema.ResourceData, m inte
 		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
 	}
 
+	// the data may contain slashes itself, so everything after the provider belongs to it
 	in := strings.Split(d.Id(), "/")
-	if len(in) != 5 {
-		return nil, fmt.Errorf("invalid Import. ID has to be of format \"{type}/{name}/{zone}/{environment}/{provider}\"")
+	if len(in) < 5 {
+		return nil, fmt.Errorf("invalid Import. ID has to be of format \"{type}/{name}/{zone}/{environment}/{provider}\" " +
+			"or \"{type}/{name}/{zone}/{environment}/{provider}@{credentials_id}\", optionally followed by \"/{data}\" " +
+			"to select one record of a set")
 	}
+	data := strings.Join(in[5:], "/")
 
 	// setting all information in a record var to perform the id calculation below
 	var record Record
@@ -350,31 +874,45 @@ func resourceDnsRecordImport(ctx context.Context, d *schema.ResourceData, m inte
 	record.Name = in[1]
 	record.zone = in[2]
 	record.environment = in[3]
-	record.provider = in[4]
+	record.provider, record.credentialsId = splitProviderIdSegment(in[4])
+
+	credentialsId := record.credentialsId
+	if credentialsId == "" {
+		credentialsId = pinto.credentialsId
+	}
+	xApiOptions, err := buildXApiOptions(record.provider, record.environment, credentialsId)
+	if err != nil {
+		return nil, err
+	}
 
 	log.Printf("[DEBUG] retrieving information for %s", d.Id())
 	request := pinto.client.RecordsApi.
-		DnsApiRecordsGet(ctx).
+		DnsApiRecordsGet(pctx).
 		Zone(record.zone).
 		Name(record.Name).
-		XApiOptions(pinto.xApiOptions).
+		XApiOptions(xApiOptions).
 		RecordType(record.Type)
 
 	r, resp, gErr := request.Execute()
-	if gErr.Error() != "" {
-		return nil, fmt.Errorf(handleClientError("IMPORT RECORD", gErr.Error(), resp))
+	if resp == nil || resp.StatusCode >= 400 {
+		err = fmt.Errorf(handleClientError("IMPORT RECORD", gErr.Error(), resp))
+		return nil, annotateTimeout(pctx, "importing", describeRecord(record), err)
 	}
+	record.id = computeRecordId(record)
+	current, err := selectImportRecord(r, record, data)
+	if err != nil {
+		return nil, err
+	}
+	record.Data = current.Data
+	record.Class = current.Class
+	record.Ttl = current.Ttl
 	if len(r) > 1 {
-		return nil, fmt.Errorf("invalid Import. More than one record matched ID %s/%s/%s", record.Type, record.Name, record.zone)
+		record.id = computeSharedRecordId(record)
 	}
-	record.Data = r[0].Data
-	record.Class = r[0].Class
-	record.Ttl = r[0].Ttl
-	record.id = computeRecordId(record)
 
 	// add gathered info to ResourceData
 	d.SetId(record.id)
-	err := d.Set(schemaProvider, record.provider)
+	err = d.Set(schemaProvider, record.provider)
 	if err != nil {
 		return nil, err
 	}
@@ -382,6 +920,16 @@ func resourceDnsRecordImport(ctx context.Context, d *schema.ResourceData, m inte
 	if err != nil {
 		return nil, err
 	}
+	err = d.Set(schemaBackend, formatBackend(record.provider, record.environment))
+	if err != nil {
+		return nil, err
+	}
+	if record.credentialsId != "" {
+		err = d.Set(schemaCredentialsId, record.credentialsId)
+		if err != nil {
+			return nil, err
+		}
+	}
 	err = d.Set("name", record.Name)
 	if err != nil {
 		return nil, err
@@ -394,11 +942,13 @@ func resourceDnsRecordImport(ctx context.Context, d *schema.ResourceData, m inte
 	if err != nil {
 		return nil, err
 	}
-	err = d.Set("ttl", *record.Ttl)
-	if err != nil {
-		return nil, err
+	if record.HasTtl() {
+		err = d.Set("ttl", *record.Ttl)
+		if err != nil {
+			return nil, err
+		}
 	}
-	err = d.Set("class", record.Class)
+	err = d.Set("class", string(record.Class))
 	if err != nil {
 		return nil, err
 	}
@@ -409,3 +959,40 @@ func resourceDnsRecordImport(ctx context.Context, d *schema.ResourceData, m inte
 
 	return []*schema.ResourceData{d}, nil
 }
+
+// selectImportRecord returns the record of a set to import. Without data the set has to consist of a single record,
+// otherwise the record whose data matches is selected
+func selectImportRecord(records []gopinto.Record, record Record, data string) (gopinto.Record, error) {
+	if len(records) == 0 {
+		return gopinto.Record{}, fmt.Errorf("invalid Import. No %s exists in environment %s of provider %s",
+			describeRecord(record), record.environment, record.provider)
+	}
+	if data == "" {
+		if len(records) > 1 {
+			return gopinto.Record{}, fmt.Errorf("invalid Import. The %s has %d values %s. Select one by appending its data "+
+				"to the ID, e.g. \"%s/%s\"", describeRecord(record), len(records), listRecordData(records), record.id, records[0].Data)
+		}
+		return records[0], nil
+	}
+	// an exact match is preferred over one that only differs in notation
+	for _, r := range records {
+		if r.Data == data {
+			return r, nil
+		}
+	}
+	for _, r := range records {
+		if recordDataEqual(r.Data, data) {
+			return r, nil
+		}
+	}
+	return gopinto.Record{}, fmt.Errorf("invalid Import. No value of the %s matches %q, the values are %s",
+		describeRecord(record), data, listRecordData(records))
+}
+
+func listRecordData(records []gopinto.Record) string {
+	data := make([]string, len(records))
+	for i, r := range records {
+		data[i] = fmt.Sprintf("%q", r.Data)
+	}
+	return strings.Join(data, ", ")
+}
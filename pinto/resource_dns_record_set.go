@@ -0,0 +1,492 @@
+package pinto
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gopinto "github.com/camaoag/project-pinto-sdk-go"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceDnsRecordSet manages all records of a name and type. Pinto deletes records by zone, name and type only, so
+// the set is the unit of ownership
+func resourceDnsRecordSet() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDnsRecordSetCreate,
+		ReadContext:   resourceDnsRecordSetRead,
+		DeleteContext: resourceDnsRecordSetDelete,
+		UpdateContext: resourceDnsRecordSetUpdate,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceDnsRecordSetImport,
+		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffBackend,
+			customizeDiffReadOnly,
+			customizeDiffZoneRules("zone"),
+			customizeDiffRecordDefaults,
+		),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			schemaProvider: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			schemaEnvironment: {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			// a changed backend or tenant must not receive the delete of the old set, so it is replaced
+			schemaBackend: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The backend the record set has been created in, as `{environment}/{provider}`.",
+			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"class": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"ttl": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+			"records": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func describeRecordSet(set Record) string {
+	return fmt.Sprintf("record set %s %s in zone %s", set.Type, set.Name, set.zone)
+}
+
+// dataToRecordSet returns the attributes shared by all records of the set and the sorted data of its records
+func dataToRecordSet(d *schema.ResourceData, provider *PintoProvider) (Record, []string, error) {
+	var set Record
+	s, err := getProvider(provider, d)
+	if err != nil {
+		return set, nil, err
+	}
+	set.provider = s
+	set.environment = getEnvironment(provider, d)
+	set.credentialsId = getResourceCredentialsId(d)
+	set.zone = d.Get("zone").(string)
+	set.Name = d.Get("name").(string)
+	set.Type = gopinto.RecordType(d.Get("type").(string))
+	set.Class = gopinto.RecordClass(d.Get("class").(string))
+	_, ok := d.GetOk("ttl")
+	if ok {
+		ttl := int32(d.Get("ttl").(int))
+		set.Ttl = &ttl
+	}
+	return set, recordSetData(d.Get("records").(*schema.Set)), nil
+}
+
+// recordSetData returns the sorted data of a set. A record without data cannot exist, so empty values are skipped
+func recordSetData(s *schema.Set) []string {
+	data := make([]string, 0, s.Len())
+	for _, v := range s.List() {
+		if v.(string) != "" {
+			data = append(data, v.(string))
+		}
+	}
+	sort.Strings(data)
+	return data
+}
+
+// recordSetDifference returns the data of a, which is not part of b
+func recordSetDifference(a []string, b []string) []string {
+	var difference []string
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			difference = append(difference, x)
+		}
+	}
+	return difference
+}
+
+// applyRecordSetDefaults uses the record_defaults of the provider if no ttl or class is planned
+func applyRecordSetDefaults(p *PintoProvider, set *Record) {
+	if !set.HasTtl() {
+		ttl32 := int32(p.recordTtl)
+		set.Ttl = &ttl32
+	}
+	if set.Class == "" {
+		set.Class = gopinto.RecordClass(p.recordClass)
+	}
+}
+
+// spendRecordSetBudget counts every record of the set that is changed
+func spendRecordSetBudget(p *PintoProvider, op string, set Record, data []string) error {
+	for _, value := range data {
+		err := p.changeBudget.spend(op, describeRecord(set)+" with data "+strconv.Quote(value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createRecordSetRecords creates a record for each data. It returns the data of the records that have been created,
+// even if an error occurred
+func createRecordSetRecords(ctx context.Context, p *PintoProvider, xApiOptions string, set Record, data []string) ([]string, error) {
+	var created []string
+	for _, value := range data {
+		record := set
+		record.Data = value
+		err := createRecord(p, xApiOptions, ctx, record)
+		if err != nil {
+			return created, annotateTimeout(ctx, "creating", describeRecord(record)+" with data "+strconv.Quote(value), err)
+		}
+		created = append(created, value)
+	}
+	return created, nil
+}
+
+func readRecordSet(ctx context.Context, p *PintoProvider, xApiOptions string, set Record) ([]gopinto.Record, error) {
+	log.Printf("[INFO] Pinto: Reading %s in environment %s of provider %s", describeRecordSet(set), set.environment, set.provider)
+	r, resp, gErr := p.client.RecordsApi.
+		DnsApiRecordsGet(ctx).
+		Zone(set.zone).
+		Name(set.Name).
+		RecordType(set.Type).
+		XApiOptions(xApiOptions).
+		Execute()
+
+	if resp == nil || resp.StatusCode >= 400 {
+		err := fmt.Errorf(handleClientError("RECORD SET READ", gErr.Error(), resp))
+		return nil, annotateTimeout(ctx, "reading", describeRecordSet(set), err)
+	}
+	return r, nil
+}
+
+func resourceDnsRecordSetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pinto := m.(*PintoProvider)
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	pctx := ctx
+	if pinto.apiKey != "" {
+		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
+	}
+
+	set, data, err := dataToRecordSet(d, pinto)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	set.id = computeRecordId(set)
+	log.Printf("[INFO] Pinto: Creating %s in environment %s of provider %s", describeRecordSet(set), set.environment, set.provider)
+
+	// the existing records would become part of the set and be deleted with it
+	existing, err := readRecordSet(pctx, pinto, xApiOptions, set)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(existing) > 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to create " + describeRecordSet(set),
+			Detail: fmt.Sprintf("The record set exists already with the values %s. Import it with the ID %q to manage it",
+				listRecordData(existing), set.id),
+		}}
+	}
+	err = spendRecordSetBudget(pinto, "create", set, data)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	applyRecordSetDefaults(pinto, &set)
+
+	created, err := createRecordSetRecords(pctx, pinto, xApiOptions, set, data)
+	if len(created) > 0 {
+		// records which have been created already are kept in state, so they are deleted with the set
+		d.SetId(set.id)
+		if setErr := d.Set("records", created); setErr != nil {
+			return diag.FromErr(setErr)
+		}
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set("ttl", *set.Ttl)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("class", string(set.Class))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set(schemaBackend, formatBackend(set.provider, set.environment))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(set.id)
+
+	return diags
+}
+
+func resourceDnsRecordSetRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pinto := m.(*PintoProvider)
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	pctx := ctx
+	if pinto.apiKey != "" {
+		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
+	}
+
+	set, data, err := dataToRecordSet(d, pinto)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	records, err := readRecordSet(pctx, pinto, xApiOptions, set)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	set.id = computeRecordId(set)
+	if len(records) == 0 {
+		log.Printf("[WARN] Pinto: Could not retrieve information for pinto_dns_record_set with id %s. Removing it from state", set.id)
+		d.SetId("")
+		return diags
+	}
+
+	current := make([]string, 0, len(records))
+	for _, r := range records {
+		value := r.Data
+		// the data of the state is kept if it only differs in notation, so it does not cause a diff
+		for _, v := range data {
+			if recordDataEqual(v, r.Data) {
+				value = v
+				break
+			}
+		}
+		current = append(current, value)
+	}
+	err = d.Set("records", current)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// all records of a set share their class and ttl
+	if records[0].Class != "" {
+		err = d.Set("class", string(records[0].Class))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if records[0].HasTtl() {
+		err = d.Set("ttl", *records[0].Ttl)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	err = d.Set(schemaBackend, formatBackend(set.provider, set.environment))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(set.id)
+
+	return diags
+}
+
+func resourceDnsRecordSetUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pinto := m.(*PintoProvider)
+
+	pctx := ctx
+	if pinto.apiKey != "" {
+		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
+	}
+
+	// the provider and environment may be set on the resource without changing its backend, which needs no request
+	if !d.HasChanges("records", "ttl", "class") {
+		return nil
+	}
+
+	set, data, err := dataToRecordSet(d, pinto)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	set.id = d.Id()
+	applyRecordSetDefaults(pinto, &set)
+
+	o, _ := d.GetChange("records")
+	oldData := recordSetData(o.(*schema.Set))
+	added := recordSetDifference(data, oldData)
+	removed := recordSetDifference(oldData, data)
+	log.Printf("[INFO] Pinto: Updating %s in environment %s of provider %s, adding %v and removing %v",
+		describeRecordSet(set), set.environment, set.provider, added, removed)
+
+	// pinto api only deletes whole sets, so removed records and changes of the ttl or class recreate the set
+	if len(removed) == 0 && !d.HasChange("ttl") && !d.HasChange("class") {
+		err = spendRecordSetBudget(pinto, "create", set, added)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		created, err := createRecordSetRecords(pctx, pinto, xApiOptions, set, added)
+		if err != nil {
+			if setErr := d.Set("records", append(oldData, created...)); setErr != nil {
+				return diag.FromErr(setErr)
+			}
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "Unable to update " + describeRecordSet(set),
+				Detail:   fmt.Sprintf("Adding the records failed, the records added so far are kept: %v", err),
+			}}
+		}
+		return nil
+	}
+
+	err = spendRecordSetBudget(pinto, "update", set, append(oldData, added...))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = deleteRecord(pinto, xApiOptions, pctx, set)
+	if err != nil {
+		// errors keep the old values in state
+		d.Partial(true)
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecordSet(set),
+			Detail: fmt.Sprintf("Deleting the old records failed, the record set is unchanged: %v",
+				annotateTimeout(pctx, "deleting", describeRecordSet(set), err)),
+		}}
+	}
+	created, err := createRecordSetRecords(pctx, pinto, xApiOptions, set, data)
+	if err != nil {
+		if len(created) == 0 {
+			// the set is gone, so it is removed from state and the next plan creates it again
+			d.SetId("")
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "Unable to update " + describeRecordSet(set) + ", the record set has been deleted",
+				Detail:   fmt.Sprintf("Creating the new records failed: %v", err),
+			}}
+		}
+		if setErr := d.Set("records", created); setErr != nil {
+			return diag.FromErr(setErr)
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to update " + describeRecordSet(set),
+			Detail: fmt.Sprintf("Creating the new records failed, only %s have been created: %v",
+				strings.Join(created, ", "), err),
+		}}
+	}
+	return nil
+}
+
+func resourceDnsRecordSetDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pinto := m.(*PintoProvider)
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	pctx := ctx
+	if pinto.apiKey != "" {
+		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
+	}
+
+	set, data, err := dataToRecordSet(d, pinto)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	set.id = d.Id()
+	err = spendRecordSetBudget(pinto, "delete", set, data)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = deleteRecord(pinto, xApiOptions, pctx, set)
+	if err != nil {
+		return diag.FromErr(annotateTimeout(pctx, "deleting", describeRecordSet(set), err))
+	}
+
+	return diags
+}
+
+// resourceDnsRecordSetImport only sets the attributes of the id, the records are read afterwards
+func resourceDnsRecordSetImport(_ context.Context, d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	in := strings.Split(d.Id(), "/")
+	if len(in) != 5 {
+		return nil, fmt.Errorf("invalid Import. ID has to be of format \"{type}/{name}/{zone}/{environment}/{provider}\" " +
+			"or \"{type}/{name}/{zone}/{environment}/{provider}@{credentials_id}\"")
+	}
+	provider, credentialsId := splitProviderIdSegment(in[4])
+
+	values := map[string]string{
+		"type":            in[0],
+		"name":            in[1],
+		"zone":            in[2],
+		schemaEnvironment: in[3],
+		schemaProvider:    provider,
+		schemaBackend:     formatBackend(provider, in[3]),
+	}
+	if credentialsId != "" {
+		values[schemaCredentialsId] = credentialsId
+	}
+	for key, value := range values {
+		err := d.Set(key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
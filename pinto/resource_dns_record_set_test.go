@@ -0,0 +1,194 @@
+package pinto
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	gopinto "github.com/camaoag/project-pinto-sdk-go"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecordSetApply(t *testing.T, p *PintoProvider, state *terraform.InstanceState, config map[string]interface{}) (*schema.ResourceData, diag.Diagnostics) {
+	r := resourceDnsRecordSet()
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), p)
+	require.NoError(t, err)
+	d, err := schema.InternalMap(r.Schema).Data(state, diff)
+	require.NoError(t, err)
+	if state == nil {
+		return d, resourceDnsRecordSetCreate(context.Background(), d, p)
+	}
+	return d, resourceDnsRecordSetUpdate(context.Background(), d, p)
+}
+
+// testRecordSetState returns the state of a set with the records 127.0.0.1 and 127.0.0.2
+func testRecordSetState(ttl string) *terraform.InstanceState {
+	attributes := map[string]string{
+		"id":          "A/www/example.com./prod1/digitalocean",
+		"zone":        "example.com.",
+		"name":        "www",
+		"type":        "A",
+		"class":       "IN",
+		"ttl":         ttl,
+		"records.#":   "2",
+		schemaBackend: "prod1/digitalocean",
+	}
+	for _, data := range []string{"127.0.0.1", "127.0.0.2"} {
+		attributes["records."+strconv.Itoa(schema.HashString(data))] = data
+	}
+	return &terraform.InstanceState{ID: attributes["id"], Attributes: attributes}
+}
+
+func testRecordSetConfig(ttl int, records ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"zone":    "example.com.",
+		"name":    "www",
+		"type":    "A",
+		"ttl":     ttl,
+		"records": records,
+	}
+}
+
+func TestRecordSetCreate(t *testing.T) {
+	var calls []string
+	p := testRecordProvider(&calls, nil, nil)
+	d, diags := testRecordSetApply(t, p, nil, testRecordSetConfig(300, "127.0.0.1", "127.0.0.2"))
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"post", "post"}, calls)
+	require.Equal(t, "A/www/example.com./prod1/digitalocean", d.Id())
+	require.Equal(t, "IN", d.Get("class"))
+
+	calls = nil
+	p = testRecordProvider(&calls, map[int]bool{2: true}, nil)
+	d, diags = testRecordSetApply(t, p, nil, testRecordSetConfig(300, "127.0.0.1", "127.0.0.2"))
+	require.True(t, diags.HasError())
+	require.Equal(t, "A/www/example.com./prod1/digitalocean", d.Id(), "created records should be kept in state")
+	require.Equal(t, 1, d.Get("records").(*schema.Set).Len())
+
+	calls = nil
+	p = testRecordProvider(&calls, nil, []gopinto.Record{{Name: "www", Type: "A", Data: "10.0.0.1"}})
+	_, diags = testRecordSetApply(t, p, nil, testRecordSetConfig(300, "127.0.0.1"))
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail, "exists already")
+	require.Empty(t, calls, "an existing set must not be changed")
+}
+
+func TestRecordSetUpdateOnlyCreatesAddedRecords(t *testing.T) {
+	var calls []string
+	p := testRecordProvider(&calls, nil, nil)
+	d, diags := testRecordSetApply(t, p, testRecordSetState("300"), testRecordSetConfig(300, "127.0.0.1", "127.0.0.2", "127.0.0.3"))
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"post"}, calls)
+	require.Equal(t, 3, d.Get("records").(*schema.Set).Len())
+}
+
+func TestRecordSetUpdateRecreatesSet(t *testing.T) {
+	var calls []string
+	p := testRecordProvider(&calls, nil, nil)
+	_, diags := testRecordSetApply(t, p, testRecordSetState("300"), testRecordSetConfig(300, "127.0.0.1"))
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"delete", "post"}, calls, "a removed record should recreate the set")
+
+	calls = nil
+	_, diags = testRecordSetApply(t, p, testRecordSetState("300"), testRecordSetConfig(600, "127.0.0.1", "127.0.0.2"))
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"delete", "post", "post"}, calls, "a changed ttl should recreate the set")
+
+	calls = nil
+	p = testRecordProvider(&calls, map[int]bool{2: true}, nil)
+	d, diags := testRecordSetApply(t, p, testRecordSetState("300"), testRecordSetConfig(600, "127.0.0.1"))
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Summary, "the record set has been deleted")
+	require.Equal(t, "", d.Id())
+}
+
+func TestRecordSetUpdateRespectsBudget(t *testing.T) {
+	var calls []string
+	p := testRecordProvider(&calls, nil, nil)
+	p.changeBudget = newChangeBudget(2)
+	_, diags := testRecordSetApply(t, p, testRecordSetState("300"), testRecordSetConfig(300, "127.0.0.1", "127.0.0.3"))
+	require.True(t, diags.HasError(), "recreating the set changes three records")
+	require.Empty(t, calls)
+}
+
+func TestRecordSetRead(t *testing.T) {
+	var calls []string
+	p := testRecordProvider(&calls, nil, []gopinto.Record{
+		{Name: "www", Type: "CNAME", Class: "IN", Data: "web.example.com.", Ttl: toInt32(300)},
+		{Name: "www", Type: "CNAME", Class: "IN", Data: "other.example.com.", Ttl: toInt32(300)},
+	})
+	d := resourceDnsRecordSet().Data(&terraform.InstanceState{
+		ID: "id",
+		Attributes: map[string]string{
+			"id":        "id",
+			"zone":      "example.com.",
+			"name":      "www",
+			"type":      "CNAME",
+			"ttl":       "3600",
+			"records.#": "1",
+			"records." + strconv.Itoa(schema.HashString("web.example.com")): "web.example.com",
+		},
+	})
+	diags := resourceDnsRecordSetRead(context.Background(), d, p)
+	require.False(t, diags.HasError())
+	require.Equal(t, "CNAME/www/example.com./prod1/digitalocean", d.Id())
+	require.Equal(t, 300, d.Get("ttl"))
+	records := d.Get("records").(*schema.Set)
+	require.Equal(t, 2, records.Len())
+	require.True(t, records.Contains("web.example.com"), "a different notation of the data should not cause drift")
+	require.True(t, records.Contains("other.example.com."))
+
+	p = testRecordProvider(&calls, nil, nil)
+	diags = resourceDnsRecordSetRead(context.Background(), d, p)
+	require.False(t, diags.HasError())
+	require.Equal(t, "", d.Id())
+}
+
+func TestRecordSetBackendChangeRequiresReplacement(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		settings    map[string]interface{}
+		provider    string
+		environment string
+		replaced    bool
+	}{
+		{"unchanged backend on resource-level", map[string]interface{}{schemaProvider: "digitalocean", schemaEnvironment: "prod1"}, "digitalocean", "prod1", false},
+		{"provider on resource-level", map[string]interface{}{schemaProvider: "hetzner"}, "digitalocean", "prod1", true},
+		{"credentials id on resource-level", map[string]interface{}{schemaCredentialsId: "4d4fe4ac"}, "digitalocean", "prod1", true},
+		{"environment on provider-level", nil, "digitalocean", "prod2", true},
+	} {
+		config := testRecordSetConfig(300, "127.0.0.1", "127.0.0.2")
+		for key, value := range tc.settings {
+			config[key] = value
+		}
+		var calls []string
+		p := testRecordProvider(&calls, nil, nil)
+		p.provider = tc.provider
+		p.environment = tc.environment
+		diff, err := resourceDnsRecordSet().Diff(context.Background(), testRecordSetState("300"), terraform.NewResourceConfigRaw(config), p)
+		require.NoError(t, err)
+		require.Equal(t, tc.replaced, diff != nil && diff.RequiresNew(), tc.name)
+		if !tc.replaced {
+			_, diags := testRecordSetApply(t, p, testRecordSetState("300"), config)
+			require.False(t, diags.HasError())
+			require.Empty(t, calls, "%s should not change the record set", tc.name)
+		}
+	}
+}
+
+func TestRecordSetImport(t *testing.T) {
+	d := resourceDnsRecordSet().Data(nil)
+	d.SetId("TXT/www/example.com./prod1/digitalocean@4d4fe4ac")
+	_, err := resourceDnsRecordSetImport(context.Background(), d, nil)
+	require.NoError(t, err)
+	require.Equal(t, "TXT", d.Get("type"))
+	require.Equal(t, "digitalocean", d.Get(schemaProvider))
+	require.Equal(t, "4d4fe4ac", d.Get(schemaCredentialsId))
+
+	d.SetId("TXT/www/example.com.")
+	_, err = resourceDnsRecordSetImport(context.Background(), d, nil)
+	require.Error(t, err)
+}
@@ -1,12 +1,23 @@
 package pinto
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
 	"regexp"
 	"testing"
+	"time"
 
+	gopinto "github.com/camaoag/project-pinto-sdk-go"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProviderPintoDnsRecords(t *testing.T) {
@@ -97,11 +108,21 @@ func TestProviderPintoDnsImportRecord(t *testing.T) {
 					),
 				},
 				resource.TestStep{
-					// TODO: Clarify the resource name
 					ResourceName:      `pinto_dns_record.env0`,
 					ImportState:       true,
 					ImportStateVerify: true,
-					ExpectError:       regexp.MustCompile("Error: invalid Import. ID has to be of format \"{type}/{name}/{zone}/{environment}/{provider}\""),
+				},
+				resource.TestStep{
+					ResourceName:      `pinto_dns_record.env0`,
+					ImportState:       true,
+					ImportStateId:     "TXT/" + name + "/env0.co./prod1/digitalocean/127.0.0.1",
+					ImportStateVerify: true,
+				},
+				resource.TestStep{
+					ResourceName:  `pinto_dns_record.env0`,
+					ImportState:   true,
+					ImportStateId: "TXT/" + name + "/env0.co.",
+					ExpectError:   regexp.MustCompile("Error: invalid Import. ID has to be of format \"{type}/{name}/{zone}/{environment}/{provider}\""),
 				},
 			},
 		},
@@ -178,3 +199,571 @@ resource "pinto_dns_record" "env0" {
 }
 `, name)
 }
+
+func TestRecordDefaultsArePlanned(t *testing.T) {
+	p := &PintoProvider{recordTtl: 300, recordClass: "CH"}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"zone": "example.com.",
+		"name": "www",
+		"type": "A",
+		"data": "127.0.0.1",
+	})
+	diff, err := resourceDnsRecord().Diff(context.Background(), nil, config, p)
+	require.NoError(t, err)
+	require.Equal(t, "300", diff.Attributes["ttl"].New)
+	require.False(t, diff.Attributes["ttl"].NewComputed)
+	require.Equal(t, "CH", diff.Attributes["class"].New)
+
+	config = terraform.NewResourceConfigRaw(map[string]interface{}{
+		"zone":  "example.com.",
+		"name":  "www",
+		"type":  "A",
+		"data":  "127.0.0.1",
+		"ttl":   60,
+		"class": "IN",
+	})
+	diff, err = resourceDnsRecord().Diff(context.Background(), nil, config, p)
+	require.NoError(t, err)
+	require.Equal(t, "60", diff.Attributes["ttl"].New)
+	require.Equal(t, "IN", diff.Attributes["class"].New)
+}
+
+// mockRecordsUpdateApiService records the order of the calls, fails the posts listed in failingPosts or whose context
+// is done and returns records on reads
+type mockRecordsUpdateApiService struct {
+	calls        *[]string
+	failingPosts map[int]bool
+	records      []gopinto.Record
+	ctx          context.Context
+}
+
+func (m mockRecordsUpdateApiService) DnsApiRecordsDelete(ctx context.Context) gopinto.ApiDnsApiRecordsDeleteRequest {
+	return gopinto.ApiDnsApiRecordsDeleteRequest{
+		ApiService: m,
+	}
+}
+
+func (m mockRecordsUpdateApiService) DnsApiRecordsDeleteExecute(r gopinto.ApiDnsApiRecordsDeleteRequest) (*http.Response, gopinto.GenericOpenAPIError) {
+	*m.calls = append(*m.calls, "delete")
+	return &http.Response{
+		StatusCode: 200,
+	}, gopinto.GenericOpenAPIError{}
+}
+
+func (m mockRecordsUpdateApiService) DnsApiRecordsGet(ctx context.Context) gopinto.ApiDnsApiRecordsGetRequest {
+	return gopinto.ApiDnsApiRecordsGetRequest{
+		ApiService: m,
+	}
+}
+
+func (m mockRecordsUpdateApiService) DnsApiRecordsGetExecute(r gopinto.ApiDnsApiRecordsGetRequest) ([]gopinto.Record, *http.Response, gopinto.GenericOpenAPIError) {
+	return m.records, &http.Response{StatusCode: 200}, gopinto.GenericOpenAPIError{}
+}
+
+func (m mockRecordsUpdateApiService) DnsApiRecordsPost(ctx context.Context) gopinto.ApiDnsApiRecordsPostRequest {
+	m.ctx = ctx
+	return gopinto.ApiDnsApiRecordsPostRequest{
+		ApiService: m,
+	}
+}
+
+func (m mockRecordsUpdateApiService) DnsApiRecordsPostExecute(r gopinto.ApiDnsApiRecordsPostRequest) (gopinto.Record, *http.Response, gopinto.GenericOpenAPIError) {
+	*m.calls = append(*m.calls, "post")
+	if m.ctx != nil && m.ctx.Err() != nil {
+		return gopinto.Record{}, nil, gopinto.GenericOpenAPIError{}
+	}
+	if m.failingPosts[len(*m.calls)] {
+		return gopinto.Record{}, &http.Response{
+			StatusCode: 503,
+			Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+		}, gopinto.GenericOpenAPIError{}
+	}
+	return newRecord("testrecord"), &http.Response{
+		StatusCode: 200,
+	}, gopinto.GenericOpenAPIError{}
+}
+
+// testRecordProvider returns a provider for digitalocean and prod1 whose records api is a mockRecordsUpdateApiService
+func testRecordProvider(calls *[]string, failingPosts map[int]bool, records []gopinto.Record) *PintoProvider {
+	return &PintoProvider{
+		client:      (*gopinto.APIClient)(NewMockClient(mockRecordsUpdateApiService{calls: calls, failingPosts: failingPosts, records: records}, mockZonesApiService{})),
+		provider:    "digitalocean",
+		environment: "prod1",
+		recordTtl:   defaultRecordTtl,
+		recordClass: defaultRecordClass,
+	}
+}
+
+func testRecordUpdate(t *testing.T, newName string, failingPosts map[int]bool, records ...gopinto.Record) (*schema.ResourceData, []string, diag.Diagnostics) {
+	return testRecordUpdateWith(context.Background(), t, map[string]interface{}{"name": newName}, failingPosts, records...)
+}
+
+// testRecordUpdateWith updates the record A www with the data 127.0.0.1 to the data 127.0.0.2 and the given changes
+func testRecordUpdateWith(ctx context.Context, t *testing.T, changes map[string]interface{}, failingPosts map[int]bool,
+	records ...gopinto.Record) (*schema.ResourceData, []string, diag.Diagnostics) {
+	var calls []string
+	p := testRecordProvider(&calls, failingPosts, records)
+	r := resourceDnsRecord()
+	state := &terraform.InstanceState{
+		ID: "old-id",
+		Attributes: map[string]string{
+			"id":    "old-id",
+			"zone":  "example.com.",
+			"name":  "www",
+			"type":  "A",
+			"class": "IN",
+			"ttl":   "3600",
+			"data":  "127.0.0.1",
+		},
+	}
+	raw := map[string]interface{}{
+		"zone": "example.com.",
+		"name": "www",
+		"type": "A",
+		"data": "127.0.0.2",
+	}
+	for key, value := range changes {
+		raw[key] = value
+	}
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), p)
+	require.NoError(t, err)
+	d, err := schema.InternalMap(r.Schema).Data(state, diff)
+	require.NoError(t, err)
+
+	diags := resourceDnsRecordUpdate(ctx, d, p)
+	return d, calls, diags
+}
+
+func TestRecordUpdateCreatesFirstIfRecordsCanCoexist(t *testing.T) {
+	d, calls, diags := testRecordUpdate(t, "www2", nil)
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"post", "delete"}, calls)
+	require.Equal(t, "www2", d.State().Attributes["name"])
+	require.Equal(t, "A/www2/example.com./prod1/digitalocean", d.Id())
+
+	d, calls, diags = testRecordUpdate(t, "www2", map[int]bool{1: true})
+	require.True(t, diags.HasError())
+	require.Equal(t, []string{"post"}, calls, "the old record should not be deleted")
+	require.Equal(t, "www", d.State().Attributes["name"], "the state should keep the old record")
+	require.Equal(t, "old-id", d.Id())
+}
+
+func TestRecordUpdateRestoresOldRecord(t *testing.T) {
+	d, calls, diags := testRecordUpdate(t, "www", nil)
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"delete", "post"}, calls)
+	require.Equal(t, "127.0.0.2", d.State().Attributes["data"])
+
+	d, calls, diags = testRecordUpdate(t, "www", map[int]bool{2: true})
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail, "the old record has been restored")
+	require.Equal(t, []string{"delete", "post", "post"}, calls)
+	require.Equal(t, "127.0.0.1", d.State().Attributes["data"], "the state should keep the old record")
+
+	d, calls, diags = testRecordUpdate(t, "www", map[int]bool{2: true, 3: true})
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Summary, "the record has been deleted")
+	require.Equal(t, []string{"delete", "post", "post"}, calls)
+	require.Equal(t, "", d.Id(), "a deleted record should be removed from state")
+}
+
+func TestRecordUpdateRecreatesOtherRecordsOfTheSet(t *testing.T) {
+	old := gopinto.Record{Name: "www", Type: "A", Class: "IN", Data: "127.0.0.1", Ttl: toInt32(3600)}
+	sibling := gopinto.Record{Name: "www", Type: "A", Class: "IN", Data: "127.0.0.9", Ttl: toInt32(300)}
+
+	d, calls, diags := testRecordUpdate(t, "www", nil, old, sibling)
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"delete", "post", "post"}, calls, "the deleted sibling should be recreated")
+	require.Equal(t, "127.0.0.2", d.State().Attributes["data"])
+	require.Equal(t, "A/www/example.com./prod1/digitalocean/127.0.0.2", d.Id(), "the id of a shared set should contain the data")
+
+	d, calls, diags = testRecordUpdate(t, "www", map[int]bool{2: true}, old, sibling)
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail, "the old record has been restored")
+	require.Equal(t, []string{"delete", "post", "post", "post"}, calls, "the old record and its sibling should be restored")
+	require.Equal(t, "127.0.0.1", d.State().Attributes["data"])
+
+	d, calls, diags = testRecordUpdate(t, "www2", nil, old, sibling)
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"post", "delete", "post"}, calls, "the sibling in the old set should be recreated")
+	require.Equal(t, "A/www2/example.com./prod1/digitalocean/127.0.0.2", d.Id(), "the new set has further values")
+
+	d, calls, diags = testRecordUpdate(t, "www", map[int]bool{3: true}, old, sibling)
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail, `"127.0.0.9"`)
+	require.Equal(t, []string{"delete", "post", "post"}, calls)
+	require.Equal(t, "127.0.0.2", d.State().Attributes["data"], "the state should point to the updated record")
+}
+
+func testRecordDelete(p *PintoProvider) (*schema.ResourceData, diag.Diagnostics) {
+	d := resourceDnsRecord().Data(&terraform.InstanceState{
+		ID: "A/www/example.com./prod1/digitalocean/127.0.0.1",
+		Attributes: map[string]string{
+			"id":    "A/www/example.com./prod1/digitalocean/127.0.0.1",
+			"zone":  "example.com.",
+			"name":  "www",
+			"type":  "A",
+			"class": "IN",
+			"ttl":   "3600",
+			"data":  "127.0.0.1",
+		},
+	})
+	return d, resourceDnsRecordDelete(context.Background(), d, p)
+}
+
+func TestRecordDeleteRecreatesOtherRecordsOfTheSet(t *testing.T) {
+	old := gopinto.Record{Name: "www", Type: "A", Class: "IN", Data: "127.0.0.1", Ttl: toInt32(3600)}
+	sibling := gopinto.Record{Name: "www", Type: "A", Class: "IN", Data: "127.0.0.9", Ttl: toInt32(300)}
+
+	var calls []string
+	_, diags := testRecordDelete(testRecordProvider(&calls, nil, []gopinto.Record{old}))
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"delete"}, calls)
+
+	calls = nil
+	_, diags = testRecordDelete(testRecordProvider(&calls, nil, []gopinto.Record{old, sibling}))
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{"delete", "post"}, calls, "the deleted sibling should be recreated")
+
+	calls = nil
+	d, diags := testRecordDelete(testRecordProvider(&calls, map[int]bool{2: true}, []gopinto.Record{old, sibling}))
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail, `"127.0.0.9"`)
+	require.Equal(t, []string{"delete", "post"}, calls)
+	require.Equal(t, "", d.Id(), "the deleted record should be removed from state")
+
+	calls = nil
+	p := testRecordProvider(&calls, nil, []gopinto.Record{old, sibling})
+	p.changeBudget = newChangeBudget(1)
+	_, diags = testRecordDelete(p)
+	require.True(t, diags.HasError(), "recreating the sibling counts against the change budget")
+	require.Empty(t, calls)
+}
+
+func TestRecordUpdateDeletesFirstIfRecordsCannotCoexist(t *testing.T) {
+	for _, tc := range []struct {
+		changes map[string]interface{}
+		id      string
+	}{
+		{map[string]interface{}{"type": "CNAME", "data": "web.example.com."}, "CNAME/www/example.com./prod1/digitalocean"},
+		{map[string]interface{}{"name": "WWW"}, "A/WWW/example.com./prod1/digitalocean"},
+	} {
+		d, calls, diags := testRecordUpdateWith(context.Background(), t, tc.changes, nil)
+		require.False(t, diags.HasError())
+		require.Equal(t, []string{"delete", "post"}, calls, "%v should delete the old record first", tc.changes)
+		require.Equal(t, tc.id, d.Id())
+	}
+
+	cname := Record{zone: "example.com.", Record: gopinto.Record{Name: "www", Type: gopinto.CNAME}}
+	a := Record{zone: "Example.com", Record: gopinto.Record{Name: "www", Type: gopinto.A}}
+	require.False(t, recordsCanCoexist(cname, a), "a CNAME cannot coexist with other records of its name")
+	a.Name = "www2"
+	require.True(t, recordsCanCoexist(cname, a))
+}
+
+func TestRecordUpdateRestoresAfterTimeout(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, ctx.Err())
+	old := gopinto.Record{Name: "www", Type: "A", Class: "IN", Data: "127.0.0.1", Ttl: toInt32(3600)}
+	sibling := gopinto.Record{Name: "www", Type: "A", Class: "IN", Data: "127.0.0.9", Ttl: toInt32(300)}
+
+	d, calls, diags := testRecordUpdateWith(ctx, t, nil, nil, old, sibling)
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail, "the old record has been restored")
+	require.Equal(t, []string{"delete", "post", "post", "post"}, calls, "the restore should not use the expired context")
+	require.Equal(t, "127.0.0.1", d.State().Attributes["data"])
+	require.NotEqual(t, "", d.Id(), "the restored record should be kept in state")
+}
+
+func testRecordRead(t *testing.T, id string, records []gopinto.Record) *schema.ResourceData {
+	var calls []string
+	p := testRecordProvider(&calls, nil, records)
+	d := resourceDnsRecord().Data(&terraform.InstanceState{
+		ID: id,
+		Attributes: map[string]string{
+			"id":    id,
+			"zone":  "example.com.",
+			"name":  "www",
+			"type":  "CNAME",
+			"class": "IN",
+			"ttl":   "3600",
+			"data":  "web.example.com",
+		},
+	})
+	diags := resourceDnsRecordRead(context.Background(), d, p)
+	require.False(t, diags.HasError())
+	return d
+}
+
+func TestRecordReadDetectsDrift(t *testing.T) {
+	other := gopinto.Record{Name: "www", Type: "CNAME", Class: "IN", Data: "other.example.com.", Ttl: toInt32(1800)}
+	managed := gopinto.Record{Name: "www", Type: "CNAME", Class: "CH", Data: "web.example.com.", Ttl: toInt32(300)}
+
+	d := testRecordRead(t, "CNAME/www/example.com./prod1/digitalocean", []gopinto.Record{other, managed})
+	require.Equal(t, "CNAME/www/example.com./prod1/digitalocean/web.example.com", d.Id())
+	require.Equal(t, "web.example.com", d.Get("data"), "a different notation of the data should not cause drift")
+	require.Equal(t, "CH", d.Get("class"))
+	require.Equal(t, 300, d.Get("ttl"))
+
+	d = testRecordRead(t, "CNAME/www/example.com./prod1/digitalocean", []gopinto.Record{other})
+	require.Equal(t, "CNAME/www/example.com./prod1/digitalocean", d.Id())
+	require.Equal(t, "other.example.com.", d.Get("data"), "the data of a single record should be adopted")
+	require.Equal(t, 1800, d.Get("ttl"))
+
+	other2 := other
+	other2.Data = "other2.example.com."
+	d = testRecordRead(t, "CNAME/www/example.com./prod1/digitalocean", []gopinto.Record{other, other2})
+	require.Equal(t, "", d.Id(), "the record should be removed from state if no record matches")
+
+	d = testRecordRead(t, "CNAME/www/example.com./prod1/digitalocean", []gopinto.Record{})
+	require.Equal(t, "", d.Id())
+
+	d = testRecordRead(t, "CNAME/www/example.com./prod1/digitalocean/web.example.com", []gopinto.Record{other})
+	require.Equal(t, "", d.Id(), "the record of a set with further values may have been deleted, so a sibling must not be adopted")
+
+	d = testRecordRead(t, "CNAME/www/example.com./prod1/digitalocean/web.example.com", []gopinto.Record{managed})
+	require.Equal(t, "CNAME/www/example.com./prod1/digitalocean", d.Id(), "a set without further values should get the plain id")
+}
+
+func TestComputeRecordId(t *testing.T) {
+	record := Record{zone: "example.com.", environment: "prod1", provider: "digitalocean"}
+	record.Type = "A"
+	record.Name = "www"
+	record.Data = "127.0.0.1"
+	require.Equal(t, "A/www/example.com./prod1/digitalocean", computeRecordId(record))
+
+	record.Data = "127.0.0.2"
+	require.Equal(t, "A/www/example.com./prod1/digitalocean", computeRecordId(record), "the id should not depend on the data")
+
+	record.credentialsId = "4d4fe4ac"
+	require.Equal(t, "A/www/example.com./prod1/digitalocean@4d4fe4ac", computeRecordId(record))
+
+	record.Type = "TXT"
+	record.Data = "key/with/slashes"
+	id := computeSharedRecordId(record)
+	require.Equal(t, "TXT/www/example.com./prod1/digitalocean@4d4fe4ac/key/with/slashes", id)
+	require.True(t, isSharedRecordId(id))
+	require.False(t, isSharedRecordId(computeRecordId(record)))
+}
+
+func TestRecordStateUpgradeV0(t *testing.T) {
+	p := &PintoProvider{provider: "digitalocean", environment: "prod1"}
+	state := map[string]interface{}{
+		"id":   "0a4d55a8d778e5022fab701977c5d840bbc486d0",
+		"zone": "example.com.",
+		"name": "www",
+		"type": "A",
+		"data": "127.0.0.1",
+		"ttl":  3600,
+	}
+	upgraded, err := resourceDnsRecordStateUpgradeV0(context.Background(), state, p)
+	require.NoError(t, err)
+	require.Equal(t, "A/www/example.com./prod1/digitalocean/127.0.0.1", upgraded["id"], "the set may have further values")
+	require.Equal(t, "127.0.0.1", upgraded["data"])
+
+	state[schemaProvider] = "hetzner"
+	state[schemaEnvironment] = "prod2"
+	state[schemaCredentialsId] = "4d4fe4ac"
+	upgraded, err = resourceDnsRecordStateUpgradeV0(context.Background(), state, p)
+	require.NoError(t, err)
+	require.Equal(t, "A/www/example.com./prod2/hetzner@4d4fe4ac/127.0.0.1", upgraded["id"])
+
+	delete(state, schemaProvider)
+	_, err = resourceDnsRecordStateUpgradeV0(context.Background(), state, &PintoProvider{})
+	require.Error(t, err, "the id cannot be computed without a provider")
+}
+
+func testRecordImport(t *testing.T, id string, records []gopinto.Record) (*schema.ResourceData, error) {
+	var calls []string
+	p := testRecordProvider(&calls, nil, records)
+	d := resourceDnsRecord().Data(nil)
+	d.SetId(id)
+	_, err := resourceDnsRecordImport(context.Background(), d, p)
+	return d, err
+}
+
+func TestRecordImportSelectsValue(t *testing.T) {
+	first := gopinto.Record{Name: "www", Type: "TXT", Class: "IN", Data: "v=spf1 -all", Ttl: toInt32(1800)}
+	second := gopinto.Record{Name: "www", Type: "TXT", Class: "IN", Data: "key/with/slashes", Ttl: toInt32(300)}
+
+	d, err := testRecordImport(t, "TXT/www/example.com./prod1/digitalocean", []gopinto.Record{first})
+	require.NoError(t, err)
+	require.Equal(t, "v=spf1 -all", d.Get("data"))
+	require.Equal(t, "TXT/www/example.com./prod1/digitalocean", d.Id())
+
+	d, err = testRecordImport(t, "TXT/www/example.com./prod1/digitalocean/key/with/slashes", []gopinto.Record{first, second})
+	require.NoError(t, err)
+	require.Equal(t, "key/with/slashes", d.Get("data"))
+	require.Equal(t, 300, d.Get("ttl"))
+	require.Equal(t, "TXT/www/example.com./prod1/digitalocean/key/with/slashes", d.Id(), "the id should select the value again")
+
+	d, err = testRecordImport(t, d.Id(), []gopinto.Record{first, second})
+	require.NoError(t, err, "the id of an imported record should be importable again")
+	require.Equal(t, "key/with/slashes", d.Get("data"))
+
+	_, err = testRecordImport(t, "TXT/www/example.com./prod1/digitalocean", []gopinto.Record{first, second})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "has 2 values")
+
+	_, err = testRecordImport(t, "TXT/www/example.com./prod1/digitalocean/missing", []gopinto.Record{first, second})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "No value of the record TXT www in zone example.com. matches \"missing\"")
+
+	_, err = testRecordImport(t, "TXT/www/example.com./prod1/digitalocean", []gopinto.Record{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "No record TXT www in zone example.com. exists")
+}
+
+func TestRecordUsesResourceLevelAccessOptions(t *testing.T) {
+	client, recorder := newXApiOptionsRecorder(t)
+	p := &PintoProvider{
+		client:        client,
+		provider:      "digitalocean",
+		environment:   "prod1",
+		credentialsId: "4d4fe4ac",
+		recordTtl:     defaultRecordTtl,
+		recordClass:   defaultRecordClass,
+	}
+	d := resourceDnsRecord().Data(nil)
+	for key, value := range map[string]string{
+		"zone":            "example.com.",
+		"name":            "www",
+		"type":            "A",
+		"data":            "127.0.0.1",
+		schemaProvider:    "hetzner",
+		schemaEnvironment: "prod2",
+	} {
+		require.NoError(t, d.Set(key, value))
+	}
+	diags := resourceDnsRecordCreate(context.Background(), d, p)
+	require.False(t, diags.HasError())
+	options := `{"access_options":{"provider":"hetzner","environment":"prod2","credentials_id":"4d4fe4ac"}}`
+	require.Equal(t, []string{options, options}, recorder.recorded(), "the set is read before the record is created")
+}
+
+func TestRecordCreateIdContainsDataOfSharedSets(t *testing.T) {
+	for _, tc := range []struct {
+		records []gopinto.Record
+		id      string
+	}{
+		{nil, "A/www/example.com./prod1/digitalocean"},
+		{[]gopinto.Record{{Name: "www", Type: "A", Data: "127.0.0.9"}}, "A/www/example.com./prod1/digitalocean/127.0.0.1"},
+	} {
+		var calls []string
+		p := testRecordProvider(&calls, nil, tc.records)
+		d := resourceDnsRecord().Data(nil)
+		for key, value := range map[string]string{"zone": "example.com.", "name": "www", "type": "A", "data": "127.0.0.1"} {
+			require.NoError(t, d.Set(key, value))
+		}
+		diags := resourceDnsRecordCreate(context.Background(), d, p)
+		require.False(t, diags.HasError())
+		require.Equal(t, []string{"post"}, calls)
+		require.Equal(t, tc.id, d.Id())
+	}
+}
+
+func TestRecordBackendChangeRequiresReplacement(t *testing.T) {
+	// the record relies on the provider-level settings
+	state := &terraform.InstanceState{
+		ID: "A/www/example.com./prod1/digitalocean",
+		Attributes: map[string]string{
+			"id":          "A/www/example.com./prod1/digitalocean",
+			"zone":        "example.com.",
+			"name":        "www",
+			"type":        "A",
+			"class":       "IN",
+			"ttl":         "3600",
+			"data":        "127.0.0.1",
+			schemaBackend: "prod1/digitalocean",
+		},
+	}
+	for _, tc := range []struct {
+		name        string
+		settings    map[string]interface{}
+		provider    string
+		environment string
+		replaced    bool
+	}{
+		{"unchanged backend on resource-level", map[string]interface{}{schemaProvider: "digitalocean", schemaEnvironment: "prod1"}, "digitalocean", "prod1", false},
+		{"unchanged settings", nil, "digitalocean", "prod1", false},
+		{"provider on resource-level", map[string]interface{}{schemaProvider: "hetzner"}, "digitalocean", "prod1", true},
+		{"environment on resource-level", map[string]interface{}{schemaEnvironment: "prod2"}, "digitalocean", "prod1", true},
+		{"credentials id on resource-level", map[string]interface{}{schemaCredentialsId: "4d4fe4ac"}, "digitalocean", "prod1", true},
+		{"provider on provider-level", nil, "hetzner", "prod1", true},
+		{"environment on provider-level", nil, "digitalocean", "prod2", true},
+	} {
+		config := map[string]interface{}{
+			"zone": "example.com.",
+			"name": "www",
+			"type": "A",
+			"data": "127.0.0.1",
+		}
+		for key, value := range tc.settings {
+			config[key] = value
+		}
+		var calls []string
+		p := testRecordProvider(&calls, nil, nil)
+		p.provider = tc.provider
+		p.environment = tc.environment
+		r := resourceDnsRecord()
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), p)
+		require.NoError(t, err)
+		require.Equal(t, tc.replaced, diff != nil && diff.RequiresNew(), tc.name)
+		if tc.replaced || diff == nil {
+			continue
+		}
+		d, err := schema.InternalMap(r.Schema).Data(state, diff)
+		require.NoError(t, err)
+		diags := resourceDnsRecordUpdate(context.Background(), d, p)
+		require.False(t, diags.HasError())
+		require.Empty(t, calls, "%s should not change the record", tc.name)
+	}
+}
+
+func TestRecordReadUsesStoredBackend(t *testing.T) {
+	client, recorder := newXApiOptionsRecorder(t)
+	p := &PintoProvider{client: client, provider: "hetzner", environment: "prod2"}
+	for _, backend := range []string{"prod1/digitalocean", ""} {
+		attributes := map[string]string{
+			"id":   "A/www/example.com./prod1/digitalocean",
+			"zone": "example.com.",
+			"name": "www",
+			"type": "A",
+			"data": "127.0.0.1",
+		}
+		if backend != "" {
+			attributes[schemaBackend] = backend
+		}
+		d := resourceDnsRecord().Data(&terraform.InstanceState{ID: attributes["id"], Attributes: attributes})
+		diags := resourceDnsRecordRead(context.Background(), d, p)
+		require.False(t, diags.HasError())
+		require.Equal(t, "prod1/digitalocean", d.Get(schemaBackend))
+	}
+	options := `{"access_options":{"provider":"digitalocean","environment":"prod1","credentials_id":""}}`
+	require.Equal(t, []string{options, options}, recorder.recorded(), "changed provider-level settings must not redirect reads")
+}
+
+func TestRecordLogsRedactCredentialsId(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	client, _ := newXApiOptionsRecorder(t)
+	p := &PintoProvider{
+		client:        client,
+		provider:      "digitalocean",
+		environment:   "prod1",
+		credentialsId: "4d4fe4ac",
+		recordTtl:     defaultRecordTtl,
+		recordClass:   defaultRecordClass,
+	}
+	d := resourceDnsRecord().Data(nil)
+	for key, value := range map[string]string{"zone": "example.com.", "name": "www", "type": "A", "data": "127.0.0.1"} {
+		require.NoError(t, d.Set(key, value))
+	}
+	diags := resourceDnsRecordCreate(context.Background(), d, p)
+	require.False(t, diags.HasError())
+	require.Contains(t, output.String(), "Using: ")
+	require.NotContains(t, output.String(), "4d4fe4ac")
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	gopinto "github.com/camaoag/project-pinto-sdk-go"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -20,6 +22,17 @@ func resourceDnsZone() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceDnsZoneImport,
 		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffBackend,
+			customizeDiffReadOnly,
+			customizeDiffZoneRules("name"),
+		),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			schemaProvider: {
 				Type:     schema.TypeString,
@@ -29,6 +42,17 @@ func resourceDnsZone() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			// a changed backend or tenant must not receive the delete of the old object, so it is replaced
+			schemaBackend: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The backend the zone has been created in, as `{environment}/{provider}`.",
+			},
+			schemaCredentialsId: {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -41,6 +65,8 @@ type Zone struct {
 	name        string
 	environment string
 	provider    string
+	// only set if the credentials id is overridden on resource-level
+	credentialsId string
 }
 
 func createZoneFromData(p *PintoProvider, d *schema.ResourceData) (Zone, error) {
@@ -52,20 +78,41 @@ func createZoneFromData(p *PintoProvider, d *schema.ResourceData) (Zone, error)
 	}
 	zone.provider = provider
 	zone.environment = environment
+	zone.credentialsId = getResourceCredentialsId(d)
 
 	zone.name = d.Get("name").(string)
 
 	return zone, nil
 }
 
+func describeZone(zone Zone) string {
+	return fmt.Sprintf("zone %s", zone.name)
+}
+
 func computeZoneId(zone Zone) string {
 	z := strings.TrimSuffix(zone.name, ".") + "."
-	return z + zone.environment + "." + zone.provider + "."
+	return z + zone.environment + "." + providerIdSegment(zone.provider, zone.credentialsId) + "."
+}
+
+// zoneIdBackend returns the pinto_backend of a zone id
+func zoneIdBackend(id string) (string, bool) {
+	s := strings.Split(id, ".")
+	if len(s) < 4 {
+		return "", false
+	}
+	provider, _ := splitProviderIdSegment(s[len(s)-2])
+	return formatBackend(provider, s[len(s)-3]), true
 }
 
-func createZone(client *gopinto.APIClient, xApiOptions string, ctx context.Context, zone Zone) error {
+func createZone(p *PintoProvider, xApiOptions string, ctx context.Context, zone Zone) error {
+	if err := checkWritable(p, "create", describeZone(zone)); err != nil {
+		return err
+	}
+	if err := checkZoneAllowed(p, "create", describeZone(zone), zone.name); err != nil {
+		return err
+	}
 	log.Printf("[INFO] Pinto: Creating zone %s in environment %s of provider %s", zone.name, zone.environment, zone.provider)
-	request := client.ZonesApi.DnsApiZonesPost(ctx).
+	request := p.client.ZonesApi.DnsApiZonesPost(ctx).
 		XApiOptions(xApiOptions).
 		CreateZoneRequestModel(gopinto.CreateZoneRequestModel{
 			Name: zone.name,
@@ -96,7 +143,15 @@ func resourceDnsZoneCreate(ctx context.Context, d *schema.ResourceData, m interf
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = createZone(pinto.client, pinto.xApiOptions, pctx, zone)
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = createZone(pinto, xApiOptions, pctx, zone)
+	if err != nil {
+		return diag.FromErr(annotateTimeout(pctx, "creating", describeZone(zone), err))
+	}
+	err = d.Set(schemaBackend, formatBackend(zone.provider, zone.environment))
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -115,36 +170,60 @@ func resourceDnsZoneRead(ctx context.Context, d *schema.ResourceData, m interfac
 		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
 	}
 
+	// states written before pinto_backend existed take the backend from their id
+	if _, ok := d.GetOk(schemaBackend); !ok {
+		if backend, ok := zoneIdBackend(d.Id()); ok {
+			err := d.Set(schemaBackend, backend)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
 	zone := d.Get("name").(string)
 	environment := getEnvironment(pinto, d)
 	provider, err := getProvider(pinto, d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	xApiOptions, err := buildXApiOptions(provider, environment, getCredentialsId(pinto, d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	log.Printf("[INFO] Pinto: Read Zone %s of environment %s for provider %s \n", zone, provider, environment)
 
 	request := pinto.client.ZonesApi.
 		DnsApiZonesZoneGet(pctx, zone).
-		XApiOptions(pinto.xApiOptions)
+		XApiOptions(xApiOptions)
 
 	z, resp, gErr := request.Execute()
-	if resp.StatusCode >= 400 {
-		return diag.Errorf(handleClientError("ZONE READ", gErr.Error(), resp))
+	if resp == nil || resp.StatusCode >= 400 {
+		err = fmt.Errorf(handleClientError("ZONE READ", gErr.Error(), resp))
+		return diag.FromErr(annotateTimeout(pctx, "reading", describeZone(Zone{name: zone}), err))
 	}
 	e := d.Set("name", z.Name)
 	if e != nil {
 		return diag.FromErr(e)
 	}
+	e = d.Set(schemaBackend, formatBackend(provider, environment))
+	if e != nil {
+		return diag.FromErr(e)
+	}
 
 	return diags
 }
 
-func deleteZone(client *gopinto.APIClient, xApiOptions string, ctx context.Context, zone Zone) error {
+func deleteZone(p *PintoProvider, xApiOptions string, ctx context.Context, zone Zone) error {
+	if err := checkWritable(p, "delete", describeZone(zone)); err != nil {
+		return err
+	}
+	if err := checkZoneAllowed(p, "delete", describeZone(zone), zone.name); err != nil {
+		return err
+	}
 	log.Printf("[INFO] Pinto: Deleting zone %s in environment %s of provider %s", zone.name, zone.environment, zone.provider)
 	// request := client.ZonesApi.ApiDnsZonesZoneDelete(ctx, zone.name).Provider(zone.provider)
-	request := client.ZonesApi.DnsApiZonesDelete(ctx).Name(zone.name).XApiOptions(xApiOptions)
+	request := p.client.ZonesApi.DnsApiZonesDelete(ctx).Name(zone.name).XApiOptions(xApiOptions)
 	resp, err := request.Execute()
-	if resp.StatusCode >= 400 {
+	if resp == nil || resp.StatusCode >= 400 {
 		return fmt.Errorf(handleClientError("ZONE DELETE", err.Error(), resp))
 	}
 	return nil
@@ -164,10 +243,14 @@ func resourceDnsZoneDelete(ctx context.Context, d *schema.ResourceData, m interf
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = deleteZone(pinto.client, pinto.xApiOptions, pctx, zone)
+	xApiOptions, err := getXApiOptions(pinto, d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	err = deleteZone(pinto, xApiOptions, pctx, zone)
+	if err != nil {
+		return diag.FromErr(annotateTimeout(pctx, "deleting", describeZone(zone), err))
+	}
 
 	return diags
 }
@@ -182,23 +265,31 @@ func resourceDnsZoneUpdate(ctx context.Context, d *schema.ResourceData, m interf
 		pctx = context.WithValue(pctx, gopinto.ContextAPIKeys, pinto.apiKey)
 	}
 
-	log.Printf("[INFO] Pinto: Updating zone %s in environment %s of provider %s", d.Id(), pinto.environment, pinto.provider)
+	// the provider and environment may be set on the resource without changing its backend, which needs no request
+	if !d.HasChange("name") {
+		return diags
+	}
 	//TODO: pinto api does not support an update of zones at the moment; instead we have to delete and create the zone
 	oldZone, err := createZoneFromData(pinto, d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	xApiOptions, err := getXApiOptions(pinto, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	log.Printf("[INFO] Pinto: Updating zone %s in environment %s of provider %s", d.Id(), oldZone.environment, oldZone.provider)
 	newZone, _ := createZoneFromData(pinto, d)
 	oldZoneS, newZoneS := d.GetChange("name")
 	oldZone.name = oldZoneS.(string)
 	newZone.name = newZoneS.(string)
-	err = deleteZone(pinto.client, pinto.xApiOptions, pctx, oldZone)
+	err = deleteZone(pinto, xApiOptions, pctx, oldZone)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(annotateTimeout(pctx, "updating", describeZone(oldZone), err))
 	}
-	err = createZone(pinto.client, pinto.xApiOptions, pctx, newZone)
+	err = createZone(pinto, xApiOptions, pctx, newZone)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(annotateTimeout(pctx, "updating", describeZone(newZone), err))
 	}
 	return diags
 }
@@ -209,22 +300,24 @@ func resourceDnsZoneImport(ctx context.Context, d *schema.ResourceData, m interf
 	log.Printf("[INFO] Pinto: Importing zone with id %s", zoneId)
 
 	if !strings.Contains(zoneId, pinto.environment) || !strings.Contains(zoneId, pinto.provider) {
-		return nil, fmt.Errorf("invalid Import. ID has to be of format \"{zoneName}.{environment}.{provider}.\"")
+		return nil, fmt.Errorf("invalid Import. ID has to be of format \"{zoneName}.{environment}.{provider}.\" " +
+			"or \"{zoneName}.{environment}.{provider}@{credentials_id}.\"")
 	}
 	zoneSplices := strings.Split(zoneId, ".")
 	// -1 because the array is of index [0,..,length-1]
 	// -3 because the last three splices contain "environment" "provider" and "" [after last . is nothing]
 	lastSplice := len(zoneSplices) - 4
-	provider := zoneSplices[len(zoneSplices)-2]
+	provider, credentialsId := splitProviderIdSegment(zoneSplices[len(zoneSplices)-2])
 	environment := zoneSplices[len(zoneSplices)-3]
 	zoneName := ""
 	for i := 0; i <= lastSplice; i++ {
 		zoneName = zoneName + zoneSplices[i] + "."
 	}
 	zone := Zone{
-		name:        zoneName,
-		environment: environment,
-		provider:    provider,
+		name:          zoneName,
+		environment:   environment,
+		provider:      provider,
+		credentialsId: credentialsId,
 	}
 	log.Printf("[DEBUG] Pinto: ZoneName = %s", zoneName)
 	err := d.Set("name", zoneName)
@@ -239,6 +332,16 @@ func resourceDnsZoneImport(ctx context.Context, d *schema.ResourceData, m interf
 	if err != nil {
 		return nil, err
 	}
+	err = d.Set(schemaBackend, formatBackend(provider, environment))
+	if err != nil {
+		return nil, err
+	}
+	if credentialsId != "" {
+		err = d.Set(schemaCredentialsId, credentialsId)
+		if err != nil {
+			return nil, err
+		}
+	}
 	d.SetId(computeZoneId(zone))
 
 	return []*schema.ResourceData{d}, nil
@@ -1,10 +1,14 @@
 package pinto
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProviderPintoDnsCreateZoneResource(t *testing.T) {
@@ -96,3 +100,67 @@ resource "pinto_dns_zone" "env0" {
   	name              = "%s"
 }`, name)
 }
+
+func TestZoneUsesResourceLevelAccessOptions(t *testing.T) {
+	client, recorder := newXApiOptionsRecorder(t)
+	p := &PintoProvider{client: client, provider: "digitalocean", environment: "prod1"}
+	d := resourceDnsZone().Data(&terraform.InstanceState{
+		ID: "example.com.prod2.hetzner.",
+		Attributes: map[string]string{
+			"id":              "example.com.prod2.hetzner.",
+			"name":            "example.com.",
+			schemaProvider:    "hetzner",
+			schemaEnvironment: "prod2",
+		},
+	})
+	diags := resourceDnsZoneDelete(context.Background(), d, p)
+	require.False(t, diags.HasError())
+	require.Equal(t, []string{`{"access_options":{"provider":"hetzner","environment":"prod2","credentials_id":""}}`},
+		recorder.recorded())
+}
+
+func TestZoneBackendChangeRequiresReplacement(t *testing.T) {
+	// the zone relies on the provider-level settings
+	state := &terraform.InstanceState{
+		ID: "example.com.prod1.digitalocean.",
+		Attributes: map[string]string{
+			"id":          "example.com.prod1.digitalocean.",
+			"name":        "example.com.",
+			schemaBackend: "prod1/digitalocean",
+		},
+	}
+	for _, tc := range []struct {
+		name        string
+		settings    map[string]interface{}
+		provider    string
+		environment string
+		replaced    bool
+	}{
+		{"unchanged backend on resource-level", map[string]interface{}{schemaProvider: "digitalocean", schemaEnvironment: "prod1"}, "digitalocean", "prod1", false},
+		{"unchanged settings", nil, "digitalocean", "prod1", false},
+		{"provider on resource-level", map[string]interface{}{schemaProvider: "hetzner"}, "digitalocean", "prod1", true},
+		{"environment on resource-level", map[string]interface{}{schemaEnvironment: "prod2"}, "digitalocean", "prod1", true},
+		{"credentials id on resource-level", map[string]interface{}{schemaCredentialsId: "4d4fe4ac"}, "digitalocean", "prod1", true},
+		{"provider on provider-level", nil, "hetzner", "prod1", true},
+		{"environment on provider-level", nil, "digitalocean", "prod2", true},
+	} {
+		config := map[string]interface{}{"name": "example.com."}
+		for key, value := range tc.settings {
+			config[key] = value
+		}
+		client, recorder := newXApiOptionsRecorder(t)
+		p := &PintoProvider{client: client, provider: tc.provider, environment: tc.environment}
+		r := resourceDnsZone()
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), p)
+		require.NoError(t, err)
+		require.Equal(t, tc.replaced, diff != nil && diff.RequiresNew(), tc.name)
+		if tc.replaced || diff == nil {
+			continue
+		}
+		d, err := schema.InternalMap(r.Schema).Data(state, diff)
+		require.NoError(t, err)
+		diags := resourceDnsZoneUpdate(context.Background(), d, p)
+		require.False(t, diags.HasError())
+		require.Empty(t, recorder.recorded(), "%s should not change the zone", tc.name)
+	}
+}
@@ -0,0 +1,144 @@
+package pinto
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryMinWait = 1
+	defaultRetryMaxWait = 30
+)
+
+// retryTransport retries failed requests with an exponential backoff. Idempotent requests are retried on network
+// errors and on 429, 502, 503 and 504 responses. All other requests (e.g. creating records) are only retried if the
+// server tells that it did not process them, i.e. on 429 and 503
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	minWait    time.Duration
+	maxWait    time.Duration
+}
+
+func newRetryTransport(base http.RoundTripper, maxRetries int, minWait time.Duration, maxWait time.Duration) *retryTransport {
+	return &retryTransport{
+		base:       base,
+		maxRetries: maxRetries,
+		minWait:    minWait,
+		maxWait:    maxWait,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.maxRetries || !t.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+		// the body of the request has been consumed and can only be retried if it can be recreated
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		wait := t.backoff(attempt, resp)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			resp.Body.Close()
+		}
+		log.Printf("[WARN] Pinto: Retrying %s %s in %s (retry %d of %d): %s", req.Method, req.URL.Path, wait, attempt+1, t.maxRetries, reason)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		// a network error does not tell whether the request has been processed
+		return isIdempotent(req.Method)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	}
+	return false
+}
+
+// backoff returns the wait time before the next attempt. A Retry-After header takes precedence over the exponential
+// backoff, but the wait never exceeds maxWait
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	wait := t.minWait << uint(attempt)
+	if resp != nil {
+		retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if ok {
+			wait = retryAfter
+		}
+	}
+	if wait > t.maxWait || wait < 0 {
+		wait = t.maxWait
+	}
+	return wait
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	wait := time.Until(date)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func validateRetryWait(minWait int, maxWait int) error {
+	if minWait > maxWait {
+		return fmt.Errorf("%s (%d) must not be greater than %s (%d)", schemaRetryMinWait, minWait, schemaRetryMaxWait, maxWait)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package pinto
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRetryTestServer(statuses ...int) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		status := statuses[len(statuses)-1]
+		if requests < len(statuses) {
+			status = statuses[requests]
+		}
+		requests++
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+	return server, &requests
+}
+
+func TestRetryTransportRetriesIdempotentRequests(t *testing.T) {
+	server, requests := newRetryTestServer(http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK)
+	defer server.Close()
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, 3, time.Millisecond, time.Millisecond)}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 3, *requests)
+}
+
+func TestRetryTransportRetriesPostOnlyIfNotProcessed(t *testing.T) {
+	server, requests := newRetryTestServer(http.StatusServiceUnavailable, http.StatusBadGateway)
+	defer server.Close()
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, 3, time.Millisecond, time.Millisecond)}
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"name":"www"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.Equal(t, 2, *requests)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"www"}`, string(body), "the body should be sent again")
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	server, requests := newRetryTestServer(http.StatusServiceUnavailable)
+	defer server.Close()
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, 2, time.Millisecond, time.Millisecond)}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, 3, *requests)
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	transport := newRetryTransport(http.DefaultTransport, 5, time.Second, 10*time.Second)
+	require.Equal(t, time.Second, transport.backoff(0, nil))
+	require.Equal(t, 4*time.Second, transport.backoff(2, nil))
+	require.Equal(t, 10*time.Second, transport.backoff(4, nil))
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"7"}}}
+	require.Equal(t, 7*time.Second, transport.backoff(0, resp))
+	resp.Header.Set("Retry-After", "120")
+	require.Equal(t, 10*time.Second, transport.backoff(0, resp))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("3")
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.True(t, wait > 59*time.Minute)
+
+	_, ok = parseRetryAfter("soon")
+	require.False(t, ok)
+}
@@ -0,0 +1,217 @@
+package pinto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// tokens which expire within this period are not handed out from the cache anymore
+	tokenCacheExpiryDelta = time.Minute
+	// a lock file older than this is considered a leftover of a crashed provider process
+	tokenCacheStaleLockAge = 30 * time.Second
+	// the holder of a lock touches it in this interval, so a slow token request does not make it stale
+	tokenCacheLockRefresh = tokenCacheStaleLockAge / 3
+	tokenCacheLockTimeout = time.Minute
+	tokenCacheLockRetry   = 50 * time.Millisecond
+)
+
+// cachedTokenSource stores access tokens on disk, so that concurrent and subsequent provider processes share a token
+// instead of requesting a new one from the token endpoint for every plan, refresh and apply
+type cachedTokenSource struct {
+	base oauth2.TokenSource
+	path string
+}
+
+// newCachedTokenSource creates a token source that caches the tokens of base in dir. The cache file is keyed by the
+// token url, the client id and the scopes
+func newCachedTokenSource(dir string, tokenUrl string, clientId string, scopes []string, base oauth2.TokenSource) (oauth2.TokenSource, error) {
+	dir, err := expandPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create token cache directory %s: %v", dir, err)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(tokenUrl + "\n" + clientId + "\n" + strings.Join(scopes, " ")))
+	key := hex.EncodeToString(h.Sum(nil))
+
+	return oauth2.ReuseTokenSource(nil, &cachedTokenSource{
+		base: base,
+		path: filepath.Join(dir, key+".json"),
+	}), nil
+}
+
+func (c *cachedTokenSource) Token() (*oauth2.Token, error) {
+	unlock, err := c.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	token, err := c.read()
+	if err != nil {
+		log.Printf("[WARN] Pinto: Ignoring unreadable token cache %s: %v", c.path, err)
+	} else if token != nil {
+		log.Printf("[DEBUG] Pinto: Using cached token from %s, expiring at %s", c.path, token.Expiry)
+		return token, nil
+	}
+
+	token, err = c.base.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token.Expiry.IsZero() {
+		// a token without expiry could never be refreshed from the cache
+		log.Printf("[DEBUG] Pinto: Not caching token without expiry")
+		return token, nil
+	}
+	err = c.write(token)
+	if err != nil {
+		log.Printf("[WARN] Pinto: Unable to write token cache %s: %v", c.path, err)
+	}
+	return token, nil
+}
+
+// read returns the cached token or nil if there is no token which is valid long enough
+func (c *cachedTokenSource) read() (*oauth2.Token, error) {
+	b, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var token oauth2.Token
+	err = json.Unmarshal(b, &token)
+	if err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" || time.Now().Add(tokenCacheExpiryDelta).After(token.Expiry) {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (c *cachedTokenSource) write(token *oauth2.Token) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, so that readers never see a partially written token
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0600)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// lock acquires a lock file next to the cache file. Lock files are used instead of flock to work on all platforms
+// the provider is released for. The lock file contains a nonce of its owner, so a lock which has been taken over as
+// stale is never removed by its former owner
+func (c *cachedTokenSource) lock() (func(), error) {
+	lockPath := c.path + ".lock"
+	owner, err := newLockOwner()
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock token cache %s: %v", c.path, err)
+	}
+	deadline := time.Now().Add(tokenCacheLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = f.Write([]byte(owner))
+			if cErr := f.Close(); err == nil {
+				err = cErr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, fmt.Errorf("unable to lock token cache %s: %v", c.path, err)
+			}
+			done := make(chan struct{})
+			go refreshLock(lockPath, owner, done)
+			return func() {
+				close(done)
+				if ownsLock(lockPath, owner) {
+					os.Remove(lockPath)
+				} else {
+					log.Printf("[WARN] Pinto: Token cache lock %s has been taken over by another process", lockPath)
+				}
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("unable to lock token cache %s: %v", c.path, err)
+		}
+		info, sErr := os.Stat(lockPath)
+		if sErr == nil && time.Since(info.ModTime()) > tokenCacheStaleLockAge {
+			log.Printf("[WARN] Pinto: Removing stale token cache lock %s", lockPath)
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout while waiting for token cache lock %s", lockPath)
+		}
+		time.Sleep(tokenCacheLockRetry)
+	}
+}
+
+// newLockOwner returns the process id and a random nonce, which identify the holder of a lock
+func newLockOwner() (string, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(nonce)), nil
+}
+
+func ownsLock(lockPath string, owner string) bool {
+	b, err := ioutil.ReadFile(lockPath)
+	return err == nil && string(b) == owner
+}
+
+// refreshLock touches the lock as long as it is held, so other processes do not remove it as stale
+func refreshLock(lockPath string, owner string, done <-chan struct{}) {
+	ticker := time.NewTicker(tokenCacheLockRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if !ownsLock(lockPath, owner) {
+				return
+			}
+			err := os.Chtimes(lockPath, now, now)
+			if err != nil {
+				log.Printf("[WARN] Pinto: Unable to refresh token cache lock %s: %v", lockPath, err)
+			}
+		}
+	}
+}
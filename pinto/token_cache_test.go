@@ -0,0 +1,93 @@
+package pinto
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+type countingTokenSource struct {
+	calls  int
+	expiry time.Duration
+}
+
+func (c *countingTokenSource) Token() (*oauth2.Token, error) {
+	c.calls++
+	return &oauth2.Token{
+		AccessToken: "token",
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(c.expiry),
+	}, nil
+}
+
+func TestCachedTokenSourceSharesTokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinto-token-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	base := &countingTokenSource{expiry: time.Hour}
+	first, err := newCachedTokenSource(dir, "https://auth.mock.co/connect/token", "client", []string{"dns"}, base)
+	require.NoError(t, err)
+	second, err := newCachedTokenSource(dir, "https://auth.mock.co/connect/token", "client", []string{"dns"}, base)
+	require.NoError(t, err)
+
+	token, err := first.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token", token.AccessToken)
+	token, err = second.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token", token.AccessToken)
+	require.Equal(t, 1, base.calls, "the second token source should use the cached token")
+
+	other, err := newCachedTokenSource(dir, "https://auth.mock.co/connect/token", "client", []string{"other"}, base)
+	require.NoError(t, err)
+	_, err = other.Token()
+	require.NoError(t, err)
+	require.Equal(t, 2, base.calls, "tokens of different scopes must not be shared")
+}
+
+func TestCachedTokenSourceRefreshesExpiringTokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinto-token-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	base := &countingTokenSource{expiry: 30 * time.Second}
+	first, err := newCachedTokenSource(dir, "https://auth.mock.co/connect/token", "client", nil, base)
+	require.NoError(t, err)
+	second, err := newCachedTokenSource(dir, "https://auth.mock.co/connect/token", "client", nil, base)
+	require.NoError(t, err)
+
+	_, err = first.Token()
+	require.NoError(t, err)
+	_, err = second.Token()
+	require.NoError(t, err)
+	require.Equal(t, 2, base.calls, "a cached token close to its expiry should be refreshed")
+}
+
+func TestCachedTokenSourceOnlyRemovesOwnLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinto-token-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := &cachedTokenSource{base: &countingTokenSource{expiry: time.Hour}, path: filepath.Join(dir, "token.json")}
+	unlock, err := c.lock()
+	require.NoError(t, err)
+	// another process removed the lock as stale and acquired it
+	require.NoError(t, ioutil.WriteFile(c.path+".lock", []byte("other"), 0600))
+	unlock()
+	b, err := ioutil.ReadFile(c.path + ".lock")
+	require.NoError(t, err, "the lock of the other process must not be removed")
+	require.Equal(t, "other", string(b))
+
+	require.NoError(t, os.Remove(c.path+".lock"))
+	unlock, err = c.lock()
+	require.NoError(t, err)
+	unlock()
+	_, err = os.Stat(c.path + ".lock")
+	require.True(t, os.IsNotExist(err), "an own lock should be removed")
+}
@@ -0,0 +1,118 @@
+package pinto
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// newHttpTransport creates the transport which is shared by the Pinto API client and the requests to the token endpoint
+func newHttpTransport(d *schema.ResourceData) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig, err := configureTLS(d)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	// without an explicit proxy the transport keeps using the proxy environment variables
+	proxyUrl, ok := d.GetOk(schemaProxyUrl)
+	if ok {
+		proxy, err := configureProxy(proxyUrl.(string), d.Get(schemaNoProxy).(string))
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = proxy
+	}
+
+	return transport, nil
+}
+
+// configureProxy routes all requests through proxyUrl, except for the hosts matched by noProxy.
+// Credentials for the proxy are taken from the user info of proxyUrl
+func configureProxy(proxyUrl string, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	u, err := url.Parse(proxyUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", schemaProxyUrl, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+		return nil, fmt.Errorf("invalid %s: unsupported scheme %q, expected http, https or socks5", schemaProxyUrl, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid %s: missing host", schemaProxyUrl)
+	}
+
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  proxyUrl,
+		HTTPSProxy: proxyUrl,
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+func configureTLS(d *schema.ResourceData) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	caCert, ok := d.GetOk(schemaCaCert)
+	if ok {
+		caPem, err := readPemOrFile(caCert.(string))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %v", schemaCaCert, err)
+		}
+		// the custom CA bundle is added to the system pool, so public endpoints keep working
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPem) {
+			return nil, fmt.Errorf("%s does not contain any PEM encoded certificate", schemaCaCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	clientCert, certOk := d.GetOk(schemaClientCert)
+	clientKey, keyOk := d.GetOk(schemaClientKey)
+	if certOk != keyOk {
+		return nil, fmt.Errorf("%s and %s have to be set together for client certificate authentication", schemaClientCert, schemaClientKey)
+	}
+	if certOk {
+		certPem, err := readPemOrFile(clientCert.(string))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %v", schemaClientCert, err)
+		}
+		keyPem, err := readPemOrFile(clientKey.(string))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %v", schemaClientKey, err)
+		}
+		cert, err := tls.X509KeyPair(certPem, keyPem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// readPemOrFile returns value itself if it contains PEM encoded data and otherwise reads the file value points to
+func readPemOrFile(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	path, err := expandPath(value)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
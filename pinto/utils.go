@@ -1,10 +1,15 @@
 package pinto
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -14,9 +19,19 @@ const (
 	schemaProvider = "pinto_provider"
 	// using name "pinto_environment" to keep the same naming schema as schemaProvider
 	schemaEnvironment = "pinto_environment"
+	// the "{environment}/{provider}" an object has been created in, resolved from the resource and provider settings
+	schemaBackend = "pinto_backend"
+
+	// separates the provider from a resource-level credentials id within resource ids, e.g. "digitalocean@{credentials_id}"
+	credentialsIdSeparator = "@"
 )
 
 func handleClientError(op string, errorString string, httpResponse *http.Response) string {
+	// without a response the request failed before reaching Pinto, e.g. because of a timeout
+	if httpResponse == nil {
+		log.Printf("[ERROR] Unable to perform operation %s. \n Reason: %s", op, errorString)
+		return errorString
+	}
 	bodyBytes, err := ioutil.ReadAll(httpResponse.Body)
 	bodyString := ""
 	if err == nil {
@@ -29,7 +44,27 @@ func handleClientError(op string, errorString string, httpResponse *http.Respons
 	}
 }
 
+// annotateTimeout names the operation and the affected record or zone if err was caused by a timeout, so it is
+// obvious which timeout has to be raised
+func annotateTimeout(ctx context.Context, op string, subject string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != context.DeadlineExceeded &&
+		!strings.Contains(err.Error(), "context deadline exceeded") &&
+		!strings.Contains(err.Error(), "Client.Timeout exceeded") {
+		return err
+	}
+	return fmt.Errorf("timeout while %s %s: %v. Consider raising the timeouts of the resource or %s of the provider",
+		op, subject, err, schemaRequestTimeout)
+}
+
+// getProvider returns the provider of the backend an object has been created in, otherwise the one set on the resource
+// and falls back to the one of the provider
 func getProvider(p *PintoProvider, d *schema.ResourceData) (string, error) {
+	if provider, _, ok := getStoredBackend(d); ok {
+		return provider, nil
+	}
 	res := ""
 	spec, ok := d.GetOk(schemaProvider)
 	if ok {
@@ -44,7 +79,12 @@ func getProvider(p *PintoProvider, d *schema.ResourceData) (string, error) {
 	return res, nil
 }
 
+// getEnvironment returns the environment of the backend an object has been created in, otherwise the one set on the
+// resource and falls back to the one of the provider
 func getEnvironment(p *PintoProvider, d *schema.ResourceData) string {
+	if _, environment, ok := getStoredBackend(d); ok {
+		return environment
+	}
 	res := ""
 	spec, ok := d.GetOk(schemaEnvironment)
 	if ok {
@@ -55,6 +95,138 @@ func getEnvironment(p *PintoProvider, d *schema.ResourceData) string {
 	return res
 }
 
+// formatBackend returns the value of pinto_backend
+func formatBackend(provider string, environment string) string {
+	return environment + "/" + provider
+}
+
+// getStoredBackend returns the provider and environment stored in pinto_backend. Once an object exists, requests go
+// to the backend it has been created in, so changed settings cannot redirect reads and deletes to another backend.
+// Data sources have no pinto_backend
+func getStoredBackend(d *schema.ResourceData) (string, string, bool) {
+	spec, ok := d.GetOk(schemaBackend)
+	if !ok {
+		return "", "", false
+	}
+	s := strings.SplitN(spec.(string), "/", 2)
+	if len(s) != 2 {
+		return "", "", false
+	}
+	return s[1], s[0], true
+}
+
+// customizeDiffBackend plans pinto_backend from the resource and provider settings. A changed backend must not receive
+// the delete of the old object, so the object is replaced, also if the backend changes through the provider-level
+// settings. Setting the provider or environment of the unchanged backend on the resource does not replace the object
+func customizeDiffBackend(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	p, ok := m.(*PintoProvider)
+	if !ok {
+		return nil
+	}
+	old, _ := d.GetChange(schemaBackend)
+	if !d.NewValueKnown(schemaProvider) || !d.NewValueKnown(schemaEnvironment) {
+		err := d.SetNewComputed(schemaBackend)
+		if err != nil || d.Id() == "" || old.(string) == "" {
+			return err
+		}
+		return d.ForceNew(schemaBackend)
+	}
+	provider := d.Get(schemaProvider).(string)
+	if provider == "" {
+		provider = p.provider
+	}
+	if provider == "" {
+		// the missing provider is reported when the object is created
+		return nil
+	}
+	environment := d.Get(schemaEnvironment).(string)
+	if environment == "" {
+		environment = p.environment
+	}
+	backend := formatBackend(provider, environment)
+	if old.(string) == backend {
+		return nil
+	}
+	err := d.SetNew(schemaBackend, backend)
+	// states written before pinto_backend existed only get the backend stored
+	if err != nil || d.Id() == "" || old.(string) == "" {
+		return err
+	}
+	return d.ForceNew(schemaBackend)
+}
+
+// getCredentialsId returns the credentials id set on the resource and falls back to the one of the provider
+func getCredentialsId(p *PintoProvider, d *schema.ResourceData) string {
+	spec, ok := d.GetOk(schemaCredentialsId)
+	if ok {
+		return spec.(string)
+	}
+	return p.credentialsId
+}
+
+// getResourceCredentialsId returns the credentials id only if it is overridden on resource-level
+func getResourceCredentialsId(d *schema.ResourceData) string {
+	spec, ok := d.GetOk(schemaCredentialsId)
+	if ok {
+		return spec.(string)
+	}
+	return ""
+}
+
+// providerIdSegment adds a resource-level credentials id to the provider part of an id.
+// Without an override the segment is the plain provider, so ids of existing resources stay stable
+func providerIdSegment(provider string, credentialsId string) string {
+	if credentialsId == "" {
+		return provider
+	}
+	return provider + credentialsIdSeparator + credentialsId
+}
+
+// splitProviderIdSegment is the inverse of providerIdSegment and returns the provider and the credentials id
+func splitProviderIdSegment(segment string) (string, string) {
+	s := strings.SplitN(segment, credentialsIdSeparator, 2)
+	if len(s) == 1 {
+		return s[0], ""
+	}
+	return s[0], s[1]
+}
+
+// getXApiOptions builds the X-Api-Options header from the effective provider, environment and credentials id of a
+// resource, so resource-level settings decide which Pinto backend receives the request
+func getXApiOptions(p *PintoProvider, d *schema.ResourceData) (string, error) {
+	provider, err := getProvider(p, d)
+	if err != nil {
+		return "", err
+	}
+	return buildXApiOptions(provider, getEnvironment(p, d), getCredentialsId(p, d))
+}
+
+func buildXApiOptions(provider string, environment string, credentialsId string) (string, error) {
+	xApiOptions, err := json.Marshal(XApiOptions{
+		AccessOptions: AccessOptions{
+			Provider:      provider,
+			Environment:   environment,
+			CredentialsId: credentialsId,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to setup xApiOptions: %v", err)
+	}
+	return string(xApiOptions), nil
+}
+
+// expandPath resolves a leading "~" to the home directory of the current user
+func expandPath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
 // TODO: Clarify missing struct in client
 type AccessOptions struct {
 	Provider      string `json:"provider"`
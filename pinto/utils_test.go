@@ -0,0 +1,48 @@
+package pinto
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderIdSegment(t *testing.T) {
+	require.Equal(t, "digitalocean", providerIdSegment("digitalocean", ""))
+	require.Equal(t, "digitalocean@4d4fe4ac-586e-4121-9603-43acf2b0ce8d",
+		providerIdSegment("digitalocean", "4d4fe4ac-586e-4121-9603-43acf2b0ce8d"))
+
+	provider, credentialsId := splitProviderIdSegment("digitalocean")
+	require.Equal(t, "digitalocean", provider)
+	require.Equal(t, "", credentialsId)
+
+	provider, credentialsId = splitProviderIdSegment("digitalocean@4d4fe4ac-586e-4121-9603-43acf2b0ce8d")
+	require.Equal(t, "digitalocean", provider)
+	require.Equal(t, "4d4fe4ac-586e-4121-9603-43acf2b0ce8d", credentialsId)
+}
+
+func TestBuildXApiOptions(t *testing.T) {
+	xApiOptions, err := buildXApiOptions("digitalocean", "prod1", "4d4fe4ac-586e-4121-9603-43acf2b0ce8d")
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{"access_options":{"provider":"digitalocean","environment":"prod1","credentials_id":"4d4fe4ac-586e-4121-9603-43acf2b0ce8d"}}`,
+		xApiOptions)
+}
+
+func TestAnnotateTimeout(t *testing.T) {
+	err := errors.New("500 Internal Server Error")
+	require.Equal(t, err, annotateTimeout(context.Background(), "creating", "zone example.com.", err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	err = annotateTimeout(ctx, "creating", "zone example.com.", errors.New("context deadline exceeded"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timeout while creating zone example.com.")
+
+	err = annotateTimeout(context.Background(), "deleting", "record A www in zone example.com.",
+		errors.New("Delete \"https://pinto.mock.co/api/dns/Records\": net/http: request canceled (Client.Timeout exceeded while awaiting headers)"))
+	require.Contains(t, err.Error(), "timeout while deleting record A www in zone example.com.")
+	require.Nil(t, annotateTimeout(ctx, "reading", "zone example.com.", nil))
+}